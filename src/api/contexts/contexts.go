@@ -4,6 +4,9 @@ import (
 	"gohan/api/models"
 	"gohan/api/models/constants"
 	"gohan/api/services"
+	"gohan/api/services/archive"
+	"gohan/api/services/drs"
+	"gohan/api/services/sanitation"
 	variantsService "gohan/api/services/variants"
 
 	es7 "github.com/elastic/go-elasticsearch/v7"
@@ -17,10 +20,13 @@ type (
 	GohanContext struct {
 		echo.Context
 		QueryParameters
-		Es7Client        *es7.Client
-		Config           *models.Config
-		IngestionService *services.IngestionService
-		VariantService   *variantsService.VariantService
+		Es7Client         *es7.Client
+		Config            *models.Config
+		IngestionService  *services.IngestionService
+		VariantService    *variantsService.VariantService
+		SanitationService *sanitation.SanitationService
+		FileArchive       archive.FileArchive
+		DrsClient         *drs.Client
 	}
 
 	// Convenient storage for relevant http context data
@@ -33,10 +39,23 @@ type (
 		Dataset    uuid.UUID
 		DataType   string
 		PositionBounds
+		QualityThresholds
 	}
 
 	PositionBounds struct {
 		LowerBound int
 		UpperBound int
+		RangeMode  constants.RangeMode
+	}
+
+	// 0 = no threshold
+	QualityThresholds struct {
+		MinGenotypeQuality int
+		MinReadDepth       int
+		MinQual            float64
+
+		// bounds of numeric INFO fields, by field id
+		MinInfo map[string]float64
+		MaxInfo map[string]float64
 	}
 )
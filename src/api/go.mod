@@ -3,7 +3,6 @@ module gohan/api
 go 1.19
 
 require (
-	github.com/Jeffail/gabs v1.4.0
 	github.com/ahmetb/go-linq v3.0.0+incompatible
 	github.com/cenkalti/backoff v2.2.1+incompatible
 	github.com/elastic/go-elasticsearch/v7 v7.17.7
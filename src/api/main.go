@@ -7,10 +7,13 @@ import (
 	serviceInfo "gohan/api/models/constants/service-info"
 	dataTypesMvc "gohan/api/mvc/data-types"
 	genesMvc "gohan/api/mvc/genes"
+	sanitationMvc "gohan/api/mvc/sanitation"
 	serviceInfoMvc "gohan/api/mvc/service-info"
 	variantsMvc "gohan/api/mvc/variants"
 	workflowsMvc "gohan/api/mvc/workflows"
 	"gohan/api/services"
+	"gohan/api/services/archive"
+	"gohan/api/services/drs"
 	"gohan/api/services/sanitation"
 	variantsService "gohan/api/services/variants"
 	"gohan/api/utils"
@@ -43,6 +46,7 @@ func main() {
 
 		"\tVCF Directory Path : %s \n"+
 		"\tGTF Directory Path : %s \n"+
+		"\tReference Genomes Directory Path : %s \n"+
 		"\tBulk Indexing Cap : %d\n"+
 		"\tFile Processing Concurrency Level : %d\n"+
 		"\tLine Processing Concurrency Level : %d\n"+
@@ -52,7 +56,9 @@ func main() {
 		"\tAPI's API-DRS Bridge Directory : %s\n"+
 		"\tDRS's API-DRS Bridge Directory : %s\n"+
 		"\tDRS Url : %s\n"+
-		"\tDRS Username : %s\n\n"+
+		"\tDRS Username : %s\n"+
+		"\tDRS Timeout : %s (%d retries, from %s)\n"+
+		"\tFile Archive : %s %s\n\n"+
 
 		"\tAuthorization Enabled : %t\n"+
 		"\tOIDC Public JWKS Url : %s\n"+
@@ -66,12 +72,15 @@ func main() {
 		cfg.SemVer,
 		cfg.Api.VcfPath,
 		cfg.Api.GtfPath,
+		cfg.Api.ReferencePath,
 		cfg.Api.BulkIndexingCap,
 		cfg.Api.FileProcessingConcurrencyLevel,
 		cfg.Api.LineProcessingConcurrencyLevel,
 		cfg.Elasticsearch.Url, cfg.Elasticsearch.Username,
 		cfg.Api.BridgeDirectory, cfg.Drs.BridgeDirectory,
 		cfg.Drs.Url, cfg.Drs.Username,
+		cfg.Drs.Timeout, cfg.Drs.MaxRetries, cfg.Drs.InitialBackoff,
+		cfg.Archive.Backend, cfg.Archive.LocalPath,
 		cfg.AuthX.IsAuthorizationEnabled,
 		cfg.AuthX.OidcPublicJwksUrl,
 		cfg.AuthX.OpaUrl,
@@ -85,16 +94,21 @@ func main() {
 	// Service Connections:
 	// -- Elasticsearch
 	es := utils.CreateEsConnection(&cfg)
-	// -- TODO: DRS ?
-	// 		(or perhaps just create an http client with credentials when necessary
-	//		rather than have one global http client ?)
+	// -- DRS
+	dc := drs.NewClient(&cfg)
 
 	// Service Singletons
 	az := services.NewAuthzService(&cfg)
 	iz := services.NewIngestionService(es, &cfg)
 	vs := variantsService.NewVariantService(&cfg)
 
-	_ = sanitation.NewSanitationService(es, &cfg)
+	ss := sanitation.NewSanitationService(es, &cfg, iz)
+
+	fa, err := archive.NewFileArchive(&cfg, dc)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
 
 	// Configure Server
 	e.Use(middleware.Recover())
@@ -108,12 +122,14 @@ func main() {
 	e.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			cc := &contexts.GohanContext{
-				Context:          c,
-				Es7Client:        es,
-				Config:           &cfg,
-				IngestionService: iz,
-				VariantService:   vs,
-				// SanitationService: ss,
+				Context:           c,
+				Es7Client:         es,
+				Config:            &cfg,
+				IngestionService:  iz,
+				VariantService:    vs,
+				SanitationService: ss,
+				FileArchive:       fa,
+				DrsClient:         dc,
 			}
 			return h(cc)
 		}
@@ -148,7 +164,8 @@ func main() {
 		gam.MandateCalibratedBounds,
 		gam.MandateCalibratedAlleles,
 		gam.MandateAssemblyIdAttribute,
-		gam.ValidatePotentialGenotypeQueryParameter)
+		gam.ValidatePotentialGenotypeQueryParameter,
+		gam.ValidateOptionalQualityThresholds)
 	e.GET("/variants/get/by/sampleId", variantsMvc.VariantsGetBySampleId,
 		// middleware
 		gam.ValidateOptionalChromosomeAttribute,
@@ -157,9 +174,16 @@ func main() {
 		gam.MandateCalibratedAlleles,
 		gam.MandateAssemblyIdAttribute,
 		gam.CalibrateOptionalSampleIdsPluralAttribute,
-		gam.ValidatePotentialGenotypeQueryParameter)
+		gam.ValidatePotentialGenotypeQueryParameter,
+		gam.ValidateOptionalQualityThresholds)
 	e.GET("/variants/get/by/documentId", variantsMvc.VariantsGetByDocumentId)
 
+	e.GET("/variants/coverage", variantsMvc.GetVariantCoverage,
+		// middleware
+		gam.ValidateOptionalChromosomeAttribute,
+		gam.OptionalDatasetAttribute,
+		gam.MandateAssemblyIdAttribute)
+
 	e.GET("/variants/count/by/variantId", variantsMvc.VariantsCountByVariantId,
 		// middleware
 		gam.ValidateOptionalChromosomeAttribute,
@@ -167,7 +191,8 @@ func main() {
 		gam.MandateCalibratedBounds,
 		gam.MandateCalibratedAlleles,
 		gam.MandateAssemblyIdAttribute,
-		gam.ValidatePotentialGenotypeQueryParameter)
+		gam.ValidatePotentialGenotypeQueryParameter,
+		gam.ValidateOptionalQualityThresholds)
 	e.GET("/variants/count/by/sampleId", variantsMvc.VariantsCountBySampleId,
 		// middleware
 		gam.ValidateOptionalChromosomeAttribute,
@@ -176,7 +201,8 @@ func main() {
 		gam.MandateCalibratedAlleles,
 		gam.MandateAssemblyIdAttribute,
 		gam.CalibrateOptionalSampleIdsSingularAttribute,
-		gam.ValidatePotentialGenotypeQueryParameter)
+		gam.ValidatePotentialGenotypeQueryParameter,
+		gam.ValidateOptionalQualityThresholds)
 
 	// --- Dataset
 	e.GET("/datasets/:dataset/summary", variantsMvc.GetDatasetSummary,
@@ -185,18 +211,49 @@ func main() {
 	e.GET("/datasets/:dataset/data-types", variantsMvc.GetDatasetDataTypes,
 		// middleware
 		gam.MandateDatasetPathParam)
+	e.GET("/datasets/:dataset/files", variantsMvc.GetDatasetFiles,
+		// middleware
+		gam.MandateDatasetPathParam)
 	e.DELETE("/datasets/:dataset/data-types/:dataType", variantsMvc.ClearDataset,
 		gam.MandateDatasetPathParam,
 		gam.MandateDataTypePathParam)
+	e.DELETE("/datasets/:dataset/variants/files/:fileId", variantsMvc.DeleteDatasetFileVariants,
+		gam.MandateDatasetPathParam)
+	e.DELETE("/datasets/:dataset/variants/samples/:sampleId", variantsMvc.DeleteDatasetSampleVariants,
+		gam.MandateDatasetPathParam)
+	e.DELETE("/datasets/:dataset/variants/region", variantsMvc.DeleteDatasetRegionVariants,
+		gam.MandateDatasetPathParam,
+		gam.ValidateOptionalChromosomeAttribute,
+		gam.MandateCalibratedBounds)
+	e.GET("/variants/deletions/:taskId", variantsMvc.GetVariantDeletionTask)
+
+	e.POST("/variants/ingestion/jobs", variantsMvc.VariantsIngestJob)
+	e.POST("/private/variants/ingestion/jobs", variantsMvc.VariantsIngestJob)
 
 	// TODO: refactor (deduplicate) --
+	// (deprecated in favour of POST /variants/ingestion/jobs)
 	e.GET("/variants/ingestion/run", variantsMvc.VariantsIngest,
 		// middleware
 		gam.MandateAssemblyIdAttribute,
 		gam.MandateDatasetAttribute)
 	e.GET("/variants/ingestion/requests", variantsMvc.GetAllVariantIngestionRequests)
+	e.DELETE("/variants/ingestion/requests/:id", variantsMvc.CancelVariantIngestionRequest)
+	e.GET("/variants/ingestion/requests/:id/quarantine", variantsMvc.GetQuarantinedVariantLines)
 	e.GET("/variants/ingestion/stats", variantsMvc.VariantsIngestionStats)
 
+	e.POST("/variants/ingestion/upload", variantsMvc.VariantsIngestUpload,
+		// middleware
+		gam.MandateAssemblyIdAttribute,
+		gam.MandateDatasetAttribute)
+	e.POST("/variants/ingestion/upload/resumable", variantsMvc.CreateVariantUploadSession,
+		// middleware
+		gam.MandateAssemblyIdAttribute,
+		gam.MandateDatasetAttribute)
+	e.GET("/variants/ingestion/upload/resumable/:uploadId", variantsMvc.GetVariantUploadSession)
+	e.PUT("/variants/ingestion/upload/resumable/:uploadId", variantsMvc.AppendToVariantUploadSession)
+
+	e.POST("/variants/validate", variantsMvc.ValidateVariantFile)
+
 	e.GET("/private/variants/ingestion/run", variantsMvc.VariantsIngest,
 		// middleware
 		gam.MandateAssemblyIdAttribute,
@@ -204,6 +261,10 @@ func main() {
 	e.GET("/private/variants/ingestion/requests", variantsMvc.GetAllVariantIngestionRequests)
 	// --
 
+	// -- Admin
+	e.GET("/admin/sanitation/runs", sanitationMvc.GetSanitationRuns)
+	e.POST("/admin/sanitation/runs", sanitationMvc.TriggerSanitationRun)
+
 	// -- Genes
 	e.GET("/genes/overview", genesMvc.GetGenesOverview)
 	e.GET("/genes/search", genesMvc.GenesGetByNomenclatureWildcard,
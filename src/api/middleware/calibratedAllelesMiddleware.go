@@ -11,6 +11,8 @@ import (
 	"github.com/labstack/echo"
 )
 
+const maxQueriedAlleles = 8
+
 func MandateCalibratedAlleles(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		gc := c.(*contexts.GohanContext)
@@ -27,11 +29,12 @@ func MandateCalibratedAlleles(next echo.HandlerFunc) echo.HandlerFunc {
 					errors.CreateSimpleBadRequest("Found an empty allele! Please double check your request!"))
 			}
 
-			// ensure no more than 2 alleles are provided at once
-			if len(alleles) > 2 {
+			// ensure no more alleles are provided at once than a call may
+			// reasonably hold (more than 2 being a polyploid query)
+			if len(alleles) > maxQueriedAlleles {
 				return echo.NewHTTPError(
 					http.StatusBadRequest,
-					errors.CreateSimpleBadRequest("Too many alleles! Please only provide 1 or 2"))
+					errors.CreateSimpleBadRequest(fmt.Sprintf("Too many alleles! Please provide between 1 and %d", maxQueriedAlleles)))
 			}
 
 			// check validity of each provided character
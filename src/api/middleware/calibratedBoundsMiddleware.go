@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"gohan/api/contexts"
+	rm "gohan/api/models/constants/range-mode"
 	"net/http"
 	"strconv"
 
@@ -53,8 +54,15 @@ func MandateCalibratedBounds(next echo.HandlerFunc) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusBadRequest, "Invalid lower and upper bounds!")
 		}
 
+		// check for an optional 'rangeMode' query parameter
+		rangeMode, rangeModeErr := rm.CastToRangeMode(c.QueryParam("rangeMode"))
+		if rangeModeErr != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid range mode! Please provide either 'start' or 'overlaps'")
+		}
+
 		gc.LowerBound = lowerBound
 		gc.UpperBound = upperBound
+		gc.RangeMode = rangeMode
 		return next(gc)
 	}
 }
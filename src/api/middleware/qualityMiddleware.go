@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"fmt"
+	"gohan/api/contexts"
+	"gohan/api/models/dtos/errors"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// INFO field ids, as allowed by the VCF specification
+var infoIdPattern = regexp.MustCompile(`^[A-Za-z_][0-9A-Za-z_.]*$`)
+
+/*
+Echo middleware to validate the optional `minGQ`, `minDP` and `minQual` HTTP query parameters,
+used to leave out low-confidence calls. Each must be non-negative when provided, and an
+integer but for `minQual` (QUAL being a float as per the VCF specification).
+
+The optional `minInfo` and `maxInfo` parameters bound numeric INFO fields, as comma-separated
+'<id>:<value>' pairs (i.e. `minInfo=DP:30,AF:0.01`)
+*/
+func ValidateOptionalQualityThresholds(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		gc := c.(*contexts.GohanContext)
+
+		for _, threshold := range []struct {
+			queryParameter string
+			destination    *int
+		}{
+			{"minGQ", &gc.MinGenotypeQuality},
+			{"minDP", &gc.MinReadDepth},
+		} {
+			qp := c.QueryParam(threshold.queryParameter)
+			if len(qp) == 0 {
+				continue
+			}
+
+			value, err := strconv.Atoi(qp)
+			if err != nil || value < 0 {
+				return echo.NewHTTPError(
+					http.StatusBadRequest,
+					errors.CreateSimpleBadRequest(fmt.Sprintf("invalid %s %s - please provide a non-negative integer", threshold.queryParameter, qp)))
+			}
+			*threshold.destination = value
+		}
+
+		if qp := c.QueryParam("minQual"); len(qp) > 0 {
+			value, err := strconv.ParseFloat(qp, 64)
+			if err != nil || value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+				return echo.NewHTTPError(
+					http.StatusBadRequest,
+					errors.CreateSimpleBadRequest(fmt.Sprintf("invalid minQual %s - please provide a non-negative number", qp)))
+			}
+			gc.MinQual = value
+		}
+
+		for _, bounds := range []struct {
+			queryParameter string
+			destination    *map[string]float64
+		}{
+			{"minInfo", &gc.MinInfo},
+			{"maxInfo", &gc.MaxInfo},
+		} {
+			qp := c.QueryParam(bounds.queryParameter)
+			if len(qp) == 0 {
+				continue
+			}
+
+			values, err := ParseInfoBounds(qp)
+			if err != nil {
+				return echo.NewHTTPError(
+					http.StatusBadRequest,
+					errors.CreateSimpleBadRequest(fmt.Sprintf("invalid %s %s - %s", bounds.queryParameter, qp, err)))
+			}
+			*bounds.destination = values
+		}
+
+		return next(gc)
+	}
+}
+
+// ParseInfoBounds reads comma-separated '<INFO id>:<number>' pairs
+func ParseInfoBounds(qp string) (map[string]float64, error) {
+	bounds := map[string]float64{}
+	for _, pair := range strings.Split(qp, ",") {
+		id, raw, found := strings.Cut(pair, ":")
+		if !found || !infoIdPattern.MatchString(id) {
+			return nil, fmt.Errorf("please provide comma-separated '<INFO id>:<number>' pairs")
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a number", raw)
+		}
+		bounds[id] = value
+	}
+	return bounds, nil
+}
@@ -0,0 +1,25 @@
+package archiveBackend
+
+import (
+	"errors"
+	"gohan/api/models/constants"
+	"strings"
+)
+
+const (
+	// files are uploaded to DRS (default)
+	DRS constants.ArchiveBackend = "drs"
+	// files are stored in a local directory, addressed by their content
+	LOCAL constants.ArchiveBackend = "local"
+)
+
+func CastToArchiveBackend(text string) (constants.ArchiveBackend, error) {
+	switch strings.ToLower(text) {
+	case "", "drs":
+		return DRS, nil
+	case "local":
+		return LOCAL, nil
+	default:
+		return DRS, errors.New("unable to parse archive backend")
+	}
+}
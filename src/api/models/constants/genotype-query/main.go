@@ -17,6 +17,9 @@ const (
 	HOMOZYGOUS_REFERENCE constants.GenotypeQuery = "HOMOZYGOUS_REFERENCE"
 	HETEROZYGOUS         constants.GenotypeQuery = "HETEROZYGOUS"
 	HOMOZYGOUS_ALTERNATE constants.GenotypeQuery = "HOMOZYGOUS_ALTERNATE"
+
+	// two or more distinct alternate alleles (i.e. 1/2), also included in HETEROZYGOUS
+	HETEROZYGOUS_ALTERNATE constants.GenotypeQuery = "HETEROZYGOUS_ALTERNATE"
 )
 
 func CastToGenoType(text string) (constants.GenotypeQuery, error) {
@@ -33,6 +36,8 @@ func CastToGenoType(text string) (constants.GenotypeQuery, error) {
 		return HETEROZYGOUS, nil
 	case "homozygous_alternate":
 		return HOMOZYGOUS_ALTERNATE, nil
+	case "heterozygous_alternate":
+		return HETEROZYGOUS_ALTERNATE, nil
 	default:
 		return UNCALLED, errors.New("unable to parse genotype query")
 	}
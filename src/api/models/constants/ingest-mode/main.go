@@ -0,0 +1,30 @@
+package ingestMode
+
+import (
+	"errors"
+	"gohan/api/models/constants"
+	"strings"
+)
+
+const (
+	// variants are added to the dataset as is (default)
+	ADD constants.IngestMode = "add"
+	// variants previously ingested from a file of the same name (or fileId)
+	// are removed once the new version of the file is ingested
+	REPLACE constants.IngestMode = "replace"
+	// the file must only bring new samples to the dataset
+	APPEND constants.IngestMode = "append"
+)
+
+func CastToIngestMode(text string) (constants.IngestMode, error) {
+	switch strings.ToLower(text) {
+	case "", "add":
+		return ADD, nil
+	case "replace":
+		return REPLACE, nil
+	case "append":
+		return APPEND, nil
+	default:
+		return ADD, errors.New("unable to parse ingestion mode")
+	}
+}
@@ -8,11 +8,15 @@ constants and enums to be used
 throughout Gohan and it's
 associated services.
 */
+type ArchiveBackend string
 type AssemblyId string
 type Chromosome string
 type GenotypeQuery string
+type IngestMode string
+type RangeMode string
 type SearchOperation string
 type SortDirection string
+type WriteMode string
 
 type Zygosity int
 type Ploidy int
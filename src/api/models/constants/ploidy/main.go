@@ -9,8 +9,11 @@ const (
 
 	Haploid
 	Diploid
+	Triploid
+	Tetraploid
+	// and so on : the ploidy of a call is its number of alleles
 )
 
 func IsKnown(value int) bool {
-	return value > int(Unknown) && value <= int(Diploid)
+	return value > int(Unknown)
 }
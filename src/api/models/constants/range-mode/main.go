@@ -0,0 +1,26 @@
+package rangeMode
+
+import (
+	"errors"
+	"gohan/api/models/constants"
+	"strings"
+)
+
+const (
+	// variants starting within the bounds (default)
+	START constants.RangeMode = "start"
+	// variants spanning any part of the bounds, such as
+	// structural variants starting before the lower bound
+	OVERLAPS constants.RangeMode = "overlaps"
+)
+
+func CastToRangeMode(text string) (constants.RangeMode, error) {
+	switch strings.ToLower(text) {
+	case "", "start":
+		return START, nil
+	case "overlaps":
+		return OVERLAPS, nil
+	default:
+		return START, errors.New("unable to parse range mode")
+	}
+}
@@ -0,0 +1,26 @@
+package writeMode
+
+import (
+	"errors"
+	"gohan/api/models/constants"
+	"strings"
+)
+
+const (
+	// documents replace any already indexed with the same id (default)
+	INDEX constants.WriteMode = "index"
+	// documents are only indexed if none exists with the same id yet,
+	// leaving those already indexed untouched
+	CREATE constants.WriteMode = "create"
+)
+
+func CastToWriteMode(text string) (constants.WriteMode, error) {
+	switch strings.ToLower(text) {
+	case "", "index":
+		return INDEX, nil
+	case "create":
+		return CREATE, nil
+	default:
+		return INDEX, errors.New("unable to parse write mode")
+	}
+}
@@ -14,10 +14,14 @@ const (
 	// Haploid (deliberately below diploid for sequential id'ing purposes)
 	Reference
 	Alternate
+
+	// Diploid or higher, with two or more distinct alternate
+	// alleles and no reference one (i.e. 1/2, as found in tumour samples)
+	HeterozygousAlternate
 )
 
 func IsKnown(value int) bool {
-	return value > int(Unknown) && value <= int(Alternate)
+	return value > int(Unknown) && value <= int(HeterozygousAlternate)
 }
 
 func ZygosityToString(zyg constants.Zygosity) string {
@@ -35,6 +39,8 @@ func ZygosityToString(zyg constants.Zygosity) string {
 		return "HOMOZYGOUS_REFERENCE"
 	case HomozygousAlternate:
 		return "HOMOZYGOUS_ALTERNATE"
+	case HeterozygousAlternate:
+		return "HETEROZYGOUS_ALTERNATE"
 	default:
 		return "UNKNOWN"
 	}
@@ -0,0 +1,51 @@
+package drs
+
+// Object is a GA4GH DRS object, as served from `GET /ga4gh/drs/v1/objects/{id}`
+// (see https://ga4gh.github.io/data-repository-service-schemas/)
+type Object struct {
+	Id            string         `json:"id"`
+	Name          string         `json:"name,omitempty"`
+	SelfUri       string         `json:"self_uri"`
+	Size          int64          `json:"size"`
+	CreatedTime   string         `json:"created_time"`
+	UpdatedTime   string         `json:"updated_time,omitempty"`
+	Version       string         `json:"version,omitempty"`
+	MimeType      string         `json:"mime_type,omitempty"`
+	Checksums     []Checksum     `json:"checksums"`
+	AccessMethods []AccessMethod `json:"access_methods,omitempty"`
+	Description   string         `json:"description,omitempty"`
+	Aliases       []string       `json:"aliases,omitempty"`
+}
+
+type Checksum struct {
+	Checksum string `json:"checksum"`
+	Type     string `json:"type"` // i.e. "sha-256", "md5"
+}
+
+// AccessMethod tells how the bytes of an object can be fetched : either straight
+// from its AccessUrl, or from one obtained with its AccessId
+type AccessMethod struct {
+	Type      string     `json:"type"` // i.e. "https", "file", "s3"
+	AccessUrl *AccessUrl `json:"access_url,omitempty"`
+	AccessId  string     `json:"access_id,omitempty"`
+	Region    string     `json:"region,omitempty"`
+}
+
+type AccessUrl struct {
+	Url     string   `json:"url"`
+	Headers []string `json:"headers,omitempty"` // i.e. "Authorization: Basic ..."
+}
+
+// Error is the body of unsuccessful DRS responses
+type Error struct {
+	Msg        string `json:"msg"`
+	StatusCode int    `json:"status_code"`
+}
+
+// IngestRequest asks DRS to ingest a file found at its end of the API-DRS bridge directory
+type IngestRequest struct {
+	Path      string
+	DatasetId string
+	ProjectId string
+	DataType  string
+}
@@ -46,6 +46,30 @@ func CreateSimpleNotFound(message string) dtos.GeneralErrorResponseDto {
 		},
 	}
 }
+func CreateSimpleConflict(message string) dtos.GeneralErrorResponseDto {
+	return dtos.GeneralErrorResponseDto{
+		Status:    409,
+		Message:   "Conflict",
+		Timestamp: time.Now(),
+		Errors: []dtos.GeneralError{
+			{
+				Message: message,
+			},
+		},
+	}
+}
+func CreateSimpleUnprocessableEntity(message string) dtos.GeneralErrorResponseDto {
+	return dtos.GeneralErrorResponseDto{
+		Status:    422,
+		Message:   "Unprocessable Entity",
+		Timestamp: time.Now(),
+		Errors: []dtos.GeneralError{
+			{
+				Message: message,
+			},
+		},
+	}
+}
 func CreateSimpleInternalServerError(message string) dtos.GeneralErrorResponseDto {
 	return dtos.GeneralErrorResponseDto{
 		Status:    500,
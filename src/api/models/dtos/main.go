@@ -1,6 +1,7 @@
 package dtos
 
 import (
+	"gohan/api/models/drs"
 	"gohan/api/models/indexes"
 	"time"
 )
@@ -39,11 +40,14 @@ type VariantCountResult struct {
 type VariantCall struct {
 	Chrom  string   `json:"chrom,omitempty"`
 	Pos    int      `json:"pos,omitempty"`
+	End    int      `json:"end,omitempty"`
+	SvType string   `json:"svType,omitempty"`
+	SvLen  int      `json:"svLen,omitempty"`
 	Id     string   `json:"id,omitempty"`
 	Ref    []string `json:"ref,omitempty"`
 	Alt    []string `json:"alt,omitempty"`
 	Format []string `json:"format,omitempty"`
-	Qual   int      `json:"qual,omitempty"`
+	Qual   float64  `json:"qual,omitempty"`
 	Filter string   `json:"filter,omitempty"`
 
 	Info []indexes.Info `json:"info,omitempty"` // TODO; refactor?
@@ -51,14 +55,62 @@ type VariantCall struct {
 	SampleId     string   `json:"sample_id"`
 	GenotypeType string   `json:"genotype_type,omitempty"`
 	Alleles      []string `json:"alleles,omitempty"`
-	// TODO: GenotypeProbability, PhredScaleLikelyhood ?
+	Phased       []bool   `json:"phased,omitempty"` // per allele separator
+
+	GenotypeProbability  []float64      `json:"genotypeProbability,omitempty"`
+	PhredScaleLikelyhood []float64      `json:"phredScaleLikelyhood,omitempty"`
+	ReadDepth            *int64         `json:"readDepth,omitempty"`
+	GenotypeQuality      *int64         `json:"genotypeQuality,omitempty"`
+	MinReadDepth         *int64         `json:"minReadDepth,omitempty"`
+	AllelicDepths        []int64        `json:"allelicDepths,omitempty"`
+	AlleleFractions      []float64      `json:"alleleFractions,omitempty"`
+	StrandBias           []int64        `json:"strandBias,omitempty"`
+	Formats              []indexes.Info `json:"formats,omitempty"`
 
 	AssemblyId string `json:"assemblyId,omitempty"`
 	Dataset    string `json:"dataset,omitempty"`
 	DocumentId string `json:"documentId,omitempty"`
 }
 
+// -- Coverage
+const (
+	CoverageVariant = "VARIANT" // a variant was called at the position
+	CoverageHomRef  = "HOM_REF" // the position lies within a gVCF reference block
+	CoverageNoData  = "NO_DATA" // neither : the position may simply not have been sequenced
+)
+
+type CoverageResponseDto struct {
+	SampleId     string             `json:"sampleId"`
+	Chromosome   string             `json:"chromosome"`
+	Position     int                `json:"position"`
+	AssemblyId   string             `json:"assemblyId"`
+	Status       string             `json:"status"`
+	VariantCount int                `json:"variantCount"`
+	Coverage     []indexes.Coverage `json:"coverage"` // reference blocks spanning the position
+}
+
+// -- Deletions
+// DeletionTaskDto reports on variants being deleted in the background
+type DeletionTaskDto struct {
+	TaskId           string `json:"taskId"`
+	Completed        bool   `json:"completed"`
+	Total            int64  `json:"total"` // documents to delete, once known
+	Deleted          int64  `json:"deleted"`
+	VersionConflicts int64  `json:"versionConflicts"`
+	ThrottledMillis  int64  `json:"throttledMillis"` // time spent waiting, to keep under the throttling rate
+	Error            string `json:"error,omitempty"`
+}
+
 // --- Dataset
+// VcfFileDto is a file ingested into a dataset, along with
+// the DRS objects it (and its index) are archived as, if any
+type VcfFileDto struct {
+	*indexes.VcfFile
+	DrsObject      *drs.Object `json:"drsObject,omitempty"`
+	TabixDrsObject *drs.Object `json:"tabixDrsObject,omitempty"`
+	DrsError       string      `json:"drsError,omitempty"` // why DRS objects are missing
+}
+
 type DataTypeSummaryResponseDto struct {
 	Count            int                    `json:"count"`
 	DataTypeSpecific map[string]interface{} `json:"data_type_specific"` // TODO: type-safety?
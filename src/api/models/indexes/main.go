@@ -2,20 +2,28 @@ package indexes
 
 import (
 	c "gohan/api/models/constants"
+	"gohan/api/models/ingest"
 	"time"
 )
 
 type Variant struct {
 	Chrom  string   `json:"chrom"`
 	Pos    int      `json:"pos"`
+	End    int      `json:"end"`              // inclusive; beyond 'pos' for structural variants and multi-base REF alleles
+	SvType string   `json:"svType,omitempty"` // i.e. DEL, DUP, INV, INS, CNV or BND
+	SvLen  int      `json:"svLen,omitempty"`  // absolute
 	Id     string   `json:"id"`
 	Ref    []string `json:"ref"`
 	Alt    []string `json:"alt"`
 	Format []string `json:"format"`
-	Qual   int      `json:"qual"`
+	Qual   float64  `json:"qual"` // -1 when missing
 	Filter string   `json:"filter"`
 	Info   []Info   `json:"info"`
 
+	// numeric values of the declared INFO fields, keyed by id (i.e. 'DP'), such
+	// that they can be range-filtered and aggregated one field at a time
+	InfoTyped map[string][]float64 `json:"infoTyped,omitempty"`
+
 	Sample Sample `json:"sample"`
 
 	FileId      string    `json:"fileId"`
@@ -24,9 +32,80 @@ type Variant struct {
 	CreatedTime time.Time `json:"createdTime"`
 }
 
+// Coverage is a gVCF reference block : an interval over which a
+// sample was confidently called homozygous reference
+type Coverage struct {
+	Chrom    string `json:"chrom"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"` // inclusive
+	SampleId string `json:"sampleId"`
+
+	GenotypeQuality *int64 `json:"genotypeQuality,omitempty"` // GQ
+	MinReadDepth    *int64 `json:"minReadDepth,omitempty"`    // MIN_DP
+	ReadDepth       *int64 `json:"readDepth,omitempty"`       // DP
+
+	FileId      string    `json:"fileId"`
+	Dataset     string    `json:"dataset"`
+	AssemblyId  string    `json:"assemblyId"`
+	CreatedTime time.Time `json:"createdTime"`
+}
+
+// Info holds an INFO field, or a sample's FORMAT field
 type Info struct {
 	Id    string `json:"id"`
-	Value string `json:"value"`
+	Value string `json:"value"` // raw, as found in the VCF
+
+	// typed values, for fields declared by an ##INFO header line
+	Type     string    `json:"type,omitempty"`
+	Number   string    `json:"number,omitempty"`
+	Integers []int64   `json:"integers,omitempty"`
+	Floats   []float64 `json:"floats,omitempty"`
+	Strings  []string  `json:"strings,omitempty"`
+	Flag     bool      `json:"flag,omitempty"`
+}
+
+// VcfFile describes an ingested .vcf.gz, whose variants all share its fileId
+type VcfFile struct {
+	FileId      string    `json:"fileId"`
+	TabixFileId string    `json:"tabixFileId"`
+	Filename    string    `json:"filename"`
+	Dataset     string    `json:"dataset"`
+	Project     string    `json:"project"`
+	AssemblyId  string    `json:"assemblyId"`
+	SampleIds   []string  `json:"sampleIds"`
+	Header      VcfHeader `json:"header"`
+
+	IngestionRequestId string                      `json:"ingestionRequestId"`
+	IngestionOptions   ingest.VariantIngestOptions `json:"ingestionOptions"`
+	CreatedTime        time.Time                   `json:"createdTime"`
+	IngestedTime       time.Time                   `json:"ingestedTime"`
+
+	// the task deleting its variants, if any; the file is forgotten once they're all deleted
+	DeletionTaskId string `json:"deletionTaskId,omitempty"`
+}
+
+// VcfHeader holds the meta-information block of a VCF
+type VcfHeader struct {
+	FileFormat string               `json:"fileFormat"`
+	Reference  string               `json:"reference,omitempty"`
+	Contigs    []VcfContig          `json:"contigs"`
+	Info       []VcfFieldDefinition `json:"info"`
+	Format     []VcfFieldDefinition `json:"format"`
+	MetaLines  []string             `json:"metaLines"` // every '##' line, as is (caller, version, etc.)
+}
+
+type VcfContig struct {
+	Id     string `json:"id"`
+	Length int64  `json:"length,omitempty"`
+}
+
+// VcfFieldDefinition describes an INFO or FORMAT field, as declared by a header line such as
+// ##INFO=<ID=AF,Number=A,Type=Float,Description="Allele Frequency">
+type VcfFieldDefinition struct {
+	Id          string `json:"id"`
+	Number      string `json:"number"` // a count, or one of 'A' (per alternate allele), 'R' (per allele), 'G' (per genotype) and '.' (unbounded)
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
 }
 
 type Sample struct {
@@ -38,7 +117,23 @@ type Variation struct {
 	Genotype             Genotype   `json:"genotype"`
 	GenotypeProbability  []float64  `json:"genotypeProbability"`  // -1 = no call (equivalent to a '.')
 	PhredScaleLikelyhood []float64  `json:"phredScaleLikelyhood"` // -1 = no call (equivalent to a '.')
-	Alleles              AllelePair `json:"alleles"`
+	Alleles              AllelePair `json:"alleles"`              // first two alleles of the call, kept for backwards compatibility
+	AllAlleles           []string   `json:"allAlleles"`           // every allele of the call, in the order of the GT field
+	AlleleOccurrences    []string   `json:"alleleOccurrences"`    // every allele of the call tagged with its occurrence number (i.e. T:1, T:2), for order-free matching
+
+	// common FORMAT fields, absent when not provided
+	ReadDepth       *int64    `json:"readDepth,omitempty"`       // DP
+	GenotypeQuality *int64    `json:"genotypeQuality,omitempty"` // GQ
+	MinReadDepth    *int64    `json:"minReadDepth,omitempty"`    // MIN_DP
+	AllelicDepths   []int64   `json:"allelicDepths,omitempty"`   // AD, per allele (ref first) : -1 = no call
+	AlleleFractions []float64 `json:"alleleFractions,omitempty"` // AF, per alternate allele : -1 = no call
+	StrandBias      []int64   `json:"strandBias,omitempty"`      // SB : -1 = no call
+
+	// every FORMAT field but GT, GP and PL (kept in genotype, genotypeProbability and
+	// phredScaleLikelyhood instead), typed according to the ##FORMAT header lines
+	Formats []Info `json:"formats,omitempty"`
+	// numeric values of the declared FORMAT fields, keyed by id, as with the INFO ones
+	FormatsTyped map[string][]float64 `json:"formatsTyped,omitempty"`
 }
 type AllelePair struct {
 	Left  string `json:"left"`
@@ -46,8 +141,11 @@ type AllelePair struct {
 }
 
 type Genotype struct {
-	Phased   bool       `json:"phased"`
-	Zygosity c.Zygosity `json:"zygosity"`
+	Phased           bool       `json:"phased"` // true only if every allele is phased
+	Zygosity         c.Zygosity `json:"zygosity"`
+	Ploidy           c.Ploidy   `json:"ploidy"`
+	AlleleIndexes    []int      `json:"alleleIndexes"`              // 0 = reference, -1 = unknown
+	PhasedSeparators []bool     `json:"phasedSeparators,omitempty"` // one per allele separator, '|' being phased and '/' unphased
 }
 
 var MAPPING_FIELDS_KEYWORD_IG256 = map[string]interface{}{
@@ -57,32 +155,59 @@ var MAPPING_FIELDS_KEYWORD_IG256 = map[string]interface{}{
 	},
 }
 var MAPPING_TEXT = map[string]interface{}{"type": "text", "fields": MAPPING_FIELDS_KEYWORD_IG256}
+var MAPPING_KEYWORD = map[string]interface{}{"type": "keyword"}
 var MAPPING_LONG = map[string]interface{}{"type": "long"}
 var MAPPING_FLOAT64 = map[string]interface{}{"type": "double"}
 var MAPPING_BOOL = map[string]interface{}{"type": "boolean"}
 var MAPPING_DATE = map[string]interface{}{"type": "date"}
 
+// Mapping of INFO and FORMAT fields, along with their values typed according to the header lines
+// (a plain object, as the existing indexes map them). Being flattened by Elasticsearch, the
+// entries of a document can't be told apart : numeric fields are filtered on through the
+// 'infoTyped' and 'formatsTyped' maps instead, whose values are keyed by field id
+var MAPPING_TYPED_FIELDS = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"id":       MAPPING_TEXT,
+		"value":    MAPPING_TEXT,
+		"type":     MAPPING_KEYWORD,
+		"number":   MAPPING_KEYWORD,
+		"integers": MAPPING_LONG,
+		"floats":   MAPPING_FLOAT64,
+		"strings":  MAPPING_TEXT,
+		"flag":     MAPPING_BOOL,
+	},
+}
+
 // This mapping is derived from the one exported by Victor from the ICHANGE instance on 2024-11-01,
 // using the following commands:
 // ./bentoctl.bash shell gohan-api
 // --> inside gohan-api container
 // curl -u $GOHAN_ES_USERNAME:$GOHAN_ES_PASSWORD bentov2-gohan-elasticsearch:9200/_mapping
 var VARIANT_INDEX_MAPPING = map[string]interface{}{
+	// typed values keyed by field id, i.e. 'infoTyped.AF'
+	"dynamic_templates": []map[string]interface{}{
+		{"info_typed": map[string]interface{}{
+			"path_match": "infoTyped.*",
+			"mapping":    MAPPING_FLOAT64,
+		}},
+		{"formats_typed": map[string]interface{}{
+			"path_match": "sample.variation.formatsTyped.*",
+			"mapping":    MAPPING_FLOAT64,
+		}},
+	},
 	"properties": map[string]interface{}{
 		"chrom":  MAPPING_TEXT,
 		"pos":    MAPPING_LONG,
+		"end":    MAPPING_LONG,
+		"svType": MAPPING_KEYWORD,
+		"svLen":  MAPPING_LONG,
 		"id":     MAPPING_TEXT,
 		"ref":    MAPPING_TEXT,
 		"alt":    MAPPING_TEXT,
 		"format": MAPPING_TEXT,
-		"qual":   MAPPING_LONG,
+		"qual":   MAPPING_FLOAT64,
 		"filter": MAPPING_TEXT,
-		"info": map[string]interface{}{
-			"properties": map[string]interface{}{
-				"id":    MAPPING_TEXT,
-				"value": MAPPING_TEXT,
-			},
-		},
+		"info":   MAPPING_TYPED_FIELDS,
 		"sample": map[string]interface{}{
 			"properties": map[string]interface{}{
 				"id": MAPPING_TEXT,
@@ -90,8 +215,11 @@ var VARIANT_INDEX_MAPPING = map[string]interface{}{
 					"properties": map[string]interface{}{
 						"genotype": map[string]interface{}{
 							"properties": map[string]interface{}{
-								"phased":   MAPPING_BOOL,
-								"zygosity": MAPPING_LONG,
+								"phased":           MAPPING_BOOL,
+								"zygosity":         MAPPING_LONG,
+								"ploidy":           MAPPING_LONG,
+								"alleleIndexes":    MAPPING_LONG,
+								"phasedSeparators": MAPPING_BOOL,
 							},
 						},
 						"alleles": map[string]interface{}{
@@ -100,8 +228,17 @@ var VARIANT_INDEX_MAPPING = map[string]interface{}{
 								"right": MAPPING_TEXT,
 							},
 						},
+						"allAlleles":           MAPPING_TEXT,
+						"alleleOccurrences":    MAPPING_KEYWORD,
 						"phredScaleLikelyhood": MAPPING_LONG,
 						"genotypeProbability":  MAPPING_FLOAT64,
+						"readDepth":            MAPPING_LONG,
+						"genotypeQuality":      MAPPING_LONG,
+						"minReadDepth":         MAPPING_LONG,
+						"allelicDepths":        MAPPING_LONG,
+						"alleleFractions":      MAPPING_FLOAT64,
+						"strandBias":           MAPPING_LONG,
+						"formats":              MAPPING_TYPED_FIELDS,
 					},
 				},
 			},
@@ -113,6 +250,113 @@ var VARIANT_INDEX_MAPPING = map[string]interface{}{
 	},
 }
 
+// Mapping of the gVCF reference blocks, stored per contig as the coverage
+// intervals over which a sample was called homozygous reference
+var COVERAGE_INDEX_MAPPING = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"chrom":           MAPPING_TEXT,
+		"start":           MAPPING_LONG,
+		"end":             MAPPING_LONG,
+		"sampleId":        MAPPING_TEXT,
+		"genotypeQuality": MAPPING_LONG,
+		"minReadDepth":    MAPPING_LONG,
+		"readDepth":       MAPPING_LONG,
+		"fileId":          MAPPING_TEXT,
+		"dataset":         MAPPING_TEXT,
+		"assemblyId":      MAPPING_TEXT,
+		"createdTime":     MAPPING_DATE,
+	},
+}
+
+// Mapping of the documents describing each ingested .vcf.gz
+var VCF_FILE_INDEX_MAPPING = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"fileId":             MAPPING_TEXT,
+		"tabixFileId":        MAPPING_TEXT,
+		"filename":           MAPPING_TEXT,
+		"dataset":            MAPPING_TEXT,
+		"project":            MAPPING_TEXT,
+		"assemblyId":         MAPPING_TEXT,
+		"sampleIds":          MAPPING_TEXT,
+		"ingestionRequestId": MAPPING_TEXT,
+		"createdTime":        MAPPING_DATE,
+		"ingestedTime":       MAPPING_DATE,
+		"deletionTaskId":     MAPPING_TEXT,
+
+		// stored, but not searchable
+		"header": map[string]interface{}{
+			"type":    "object",
+			"enabled": false,
+		},
+	},
+}
+
+// Mapping of the documents backing the durable variant
+// and gene ingestion request store
+var INGESTION_REQUEST_INDEX_MAPPING = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"id":        MAPPING_TEXT,
+		"filename":  MAPPING_TEXT,
+		"fileId":    MAPPING_TEXT,
+		"state":     MAPPING_TEXT,
+		"message":   MAPPING_TEXT,
+		"createdAt": MAPPING_TEXT,
+		"updatedAt": MAPPING_TEXT,
+
+		"idempotencyKey": MAPPING_TEXT,
+	},
+}
+
+// Mapping of the documents backing the variant ingestion jobs,
+// which are identified by their idempotency key
+var INGESTION_JOB_INDEX_MAPPING = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"id":             MAPPING_TEXT,
+		"idempotencyKey": MAPPING_TEXT,
+		"createdAt":      MAPPING_DATE,
+	},
+}
+
+// Mapping of the reports of the sanitation runs
+var SANITATION_RUN_INDEX_MAPPING = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"id":          MAPPING_TEXT,
+		"trigger":     MAPPING_TEXT,
+		"state":       MAPPING_TEXT,
+		"startedAt":   MAPPING_DATE,
+		"completedAt": MAPPING_DATE,
+
+		// stored, but not searchable
+		"jobs": map[string]interface{}{
+			"type":    "object",
+			"enabled": false,
+		},
+	},
+}
+
+// Mapping of the lines of VCFs that couldn't be ingested, looked up by ingestion request
+var QUARANTINE_INDEX_MAPPING = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"requestId":   MAPPING_KEYWORD,
+		"filename":    MAPPING_TEXT,
+		"line":        MAPPING_LONG,
+		"raw":         map[string]interface{}{"type": "text", "index": false},
+		"reason":      MAPPING_TEXT,
+		"createdTime": MAPPING_DATE,
+	},
+}
+
+// QuarantinedLine is a line of a VCF that couldn't be ingested, kept
+// as is under the id of its ingestion request, along with the reasons why
+type QuarantinedLine struct {
+	RequestId   string    `json:"requestId"`
+	Filename    string    `json:"filename"`
+	Line        int64     `json:"line"` // 1-based, header included
+	Raw         string    `json:"raw"`
+	Reason      string    `json:"reason"`
+	CreatedTime time.Time `json:"createdTime"`
+}
+
 type Gene struct {
 	Name       string `json:"name"`
 	Chrom      string `json:"chrom"`
@@ -1,6 +1,12 @@
 package ingest
 
 import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"gohan/api/models/constants"
+
 	"github.com/google/uuid"
 )
 
@@ -12,15 +18,193 @@ const (
 	Running           = "Running"
 	Done              = "Done"
 	Error             = "Error"
+	Cancelled         = "Cancelled"
+
+	// Interrupted requests were Queued, Downloading or Running
+	// when the API was last shut down, and never completed
+	Interrupted = "Interrupted"
 )
 
 type VariantIngestRequest struct {
 	Id        uuid.UUID `json:"id"`
 	Filename  string    `json:"filename"`
+	FileId    string    `json:"fileId,omitempty"`
 	State     State     `json:"state"`
 	Message   string    `json:"message"`
 	CreatedAt string    `json:"createdAt"`
 	UpdatedAt string    `json:"updatedAt"`
+
+	// key of the job this request was submitted with, if any
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+
+	Progress *VariantIngestProgress `json:"progress,omitempty"`
+
+	// problems found in the file while it was read
+	Validation *VcfValidationReport `json:"validation,omitempty"`
+}
+
+// VariantIngestOptions holds the settings altering
+// how the variants of a .vcf.gz file are ingested
+type VariantIngestOptions struct {
+	FilterOutReferences bool `json:"filterOutReferences"`
+	// gVCF reference blocks (<NON_REF> or <*> as the only ALT) are stored as
+	// coverage intervals rather than variants
+	Gvcf bool `json:"gvcf"`
+	// multi-allelic records are split into biallelic ones, with recalculated genotypes
+	Decompose bool `json:"decompose"`
+	// bases shared by the REF and ALT alleles are trimmed (i.e. REF=CTT ALT=CT becomes REF=CT ALT=C)
+	Normalize bool `json:"normalize"`
+	// indels are shifted to their leftmost equivalent position, given a reference genome for the assembly
+	LeftAlign bool `json:"leftAlign"`
+	// the file is read and validated, but nothing is uploaded to DRS nor indexed
+	DryRun bool `json:"dryRun"`
+	// the ingestion fails once more lines than this are quarantined (0 for no limit)
+	MaxErrors int `json:"maxErrors"`
+	// whether variants already indexed under the same id are replaced ('index') or kept ('create')
+	WriteMode constants.WriteMode `json:"writeMode"`
+	// how the file relates to what the dataset already holds ('add', 'replace' or 'append')
+	Mode constants.IngestMode `json:"mode"`
+}
+
+// VariantIngestJobRequestDTO is the body of a request to the variant ingestion job API.
+// Either 'files' or 'directory' (relative to the VCF path), or 'drsObjectIds' must be provided
+type VariantIngestJobRequestDTO struct {
+	Files        []string             `json:"files"`
+	Directory    string               `json:"directory,omitempty"`
+	DrsObjectIds []string             `json:"drsObjectIds,omitempty"` // ids (or DRS URIs) of objects already held by DRS
+	AssemblyId   string               `json:"assemblyId"`
+	Dataset      string               `json:"dataset"`
+	Project      string               `json:"project"`
+	Options      VariantIngestOptions `json:"options"`
+}
+
+// VariantIngestJob groups the variant ingestion requests created by a single
+// call to the job API. Jobs submitted with an idempotency key are kept, such
+// that resubmitting the same key returns the original job rather than a new one
+type VariantIngestJob struct {
+	Id             uuid.UUID                  `json:"id"`
+	IdempotencyKey string                     `json:"idempotencyKey,omitempty"`
+	Request        VariantIngestJobRequestDTO `json:"request"`
+	Requests       []IngestResponseDTO        `json:"requests"`
+	CreatedAt      time.Time                  `json:"createdAt"`
+}
+
+// VariantIngestProgress holds the live counters of a single variant
+// ingestion request. Counters are updated concurrently, and must therefore
+// only be modified with the sync/atomic package
+type VariantIngestProgress struct {
+	LinesRead                   int64 `json:"linesRead"`
+	BytesRead                   int64 `json:"bytesRead"` // compressed bytes consumed from the gzip stream
+	TotalBytes                  int64 `json:"totalBytes"`
+	DocumentsQueued             int64 `json:"documentsQueued"`
+	DocumentsIndexed            int64 `json:"documentsIndexed"`
+	DocumentsFailed             int64 `json:"documentsFailed"`
+	DocumentsAlreadyIndexed     int64 `json:"documentsAlreadyIndexed"` // left untouched, in the 'create' write mode
+	SkippedHomozygousReferences int64 `json:"skippedHomozygousReferences"`
+	ReferenceBlocks             int64 `json:"referenceBlocks"` // gVCF reference block calls, stored as coverage intervals
+	RefChecked                  int64 `json:"refChecked"`      // rows whose REF allele was compared with the reference genome
+	RefMismatches               int64 `json:"refMismatches"`
+	LeftAligned                 int64 `json:"leftAligned"`
+	LinesQuarantined            int64 `json:"linesQuarantined"` // malformed lines, left out rather than indexed
+	StartedAt                   int64 `json:"-"`                // unix nanoseconds, serialized as the 'startedAt' time
+}
+
+// MarshalJSON takes a consistent snapshot of the counters and
+// derives the completion percentage and the estimated time left
+func (p *VariantIngestProgress) MarshalJSON() ([]byte, error) {
+	type progressCounters VariantIngestProgress
+	snapshot := struct {
+		progressCounters
+		StartedAt   time.Time `json:"startedAt"`
+		PercentDone float64   `json:"percentDone"`
+		EtaSeconds  float64   `json:"etaSeconds"`
+	}{
+		progressCounters: progressCounters{
+			LinesRead:                   atomic.LoadInt64(&p.LinesRead),
+			BytesRead:                   atomic.LoadInt64(&p.BytesRead),
+			TotalBytes:                  atomic.LoadInt64(&p.TotalBytes),
+			DocumentsQueued:             atomic.LoadInt64(&p.DocumentsQueued),
+			DocumentsIndexed:            atomic.LoadInt64(&p.DocumentsIndexed),
+			DocumentsFailed:             atomic.LoadInt64(&p.DocumentsFailed),
+			DocumentsAlreadyIndexed:     atomic.LoadInt64(&p.DocumentsAlreadyIndexed),
+			SkippedHomozygousReferences: atomic.LoadInt64(&p.SkippedHomozygousReferences),
+			ReferenceBlocks:             atomic.LoadInt64(&p.ReferenceBlocks),
+			RefChecked:                  atomic.LoadInt64(&p.RefChecked),
+			RefMismatches:               atomic.LoadInt64(&p.RefMismatches),
+			LeftAligned:                 atomic.LoadInt64(&p.LeftAligned),
+			LinesQuarantined:            atomic.LoadInt64(&p.LinesQuarantined),
+		},
+	}
+	if startedAt := atomic.LoadInt64(&p.StartedAt); startedAt != 0 {
+		snapshot.StartedAt = time.Unix(0, startedAt)
+	}
+
+	if snapshot.TotalBytes > 0 && snapshot.BytesRead > 0 {
+		snapshot.PercentDone = 100 * float64(snapshot.BytesRead) / float64(snapshot.TotalBytes)
+
+		// assume a constant throughput since the start
+		elapsed := time.Since(snapshot.StartedAt).Seconds()
+		if !snapshot.StartedAt.IsZero() && snapshot.PercentDone < 100 {
+			snapshot.EtaSeconds = elapsed * float64(snapshot.TotalBytes-snapshot.BytesRead) / float64(snapshot.BytesRead)
+		}
+	}
+
+	return json.Marshal(snapshot)
+}
+
+// UnmarshalJSON restores the counters of a request persisted with MarshalJSON
+func (p *VariantIngestProgress) UnmarshalJSON(data []byte) error {
+	type progressCounters VariantIngestProgress
+	restored := struct {
+		*progressCounters
+		StartedAt time.Time `json:"startedAt"`
+	}{progressCounters: (*progressCounters)(p)}
+
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return err
+	}
+	if !restored.StartedAt.IsZero() {
+		p.StartedAt = restored.StartedAt.UnixNano()
+	}
+	return nil
+}
+
+// VcfValidationReport describes the problems found while reading a .vcf.gz.
+// Line-level errors are counted in full but only the first ones are listed
+type VcfValidationReport struct {
+	Filename           string         `json:"filename"`
+	Valid              bool           `json:"valid"` // no line-level errors, malformed genotypes included
+	SampleCount        int            `json:"sampleCount"`
+	RecordCount        int64          `json:"recordCount"`
+	ContigsSeen        []string       `json:"contigsSeen"`
+	UnknownContigs     []string       `json:"unknownContigs"` // absent from the '##contig' lines or the reference genome
+	MalformedGenotypes int64          `json:"malformedGenotypes"`
+	ErrorCount         int64          `json:"errorCount"`
+	Errors             []VcfLineError `json:"errors"`
+}
+
+// VcfLineError is a problem found on a given line of a VCF (1-based),
+// or with the file as a whole when the line is 0
+type VcfLineError struct {
+	Line   int64  `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// VariantUploadSession tracks a resumable, chunked .vcf.gz upload
+// along with the ingestion parameters to use once it is complete
+type VariantUploadSession struct {
+	Id        uuid.UUID `json:"id"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"` // number of bytes received so far
+	Checksum  string    `json:"checksum"`
+	CreatedAt string    `json:"createdAt"`
+	UpdatedAt string    `json:"updatedAt"`
+
+	AssemblyId string               `json:"assemblyId"`
+	Dataset    uuid.UUID            `json:"dataset"`
+	Project    string               `json:"project"`
+	Options    VariantIngestOptions `json:"options"`
 }
 
 type GeneIngestRequest struct {
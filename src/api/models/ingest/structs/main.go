@@ -1,13 +1,22 @@
 package structs
 
 import (
+	"context"
+	"gohan/api/models/constants"
 	"gohan/api/models/indexes"
+	"gohan/api/models/ingest"
 	"sync"
 )
 
 type IngestionQueueStructure struct {
-	Variant   *indexes.Variant
-	WaitGroup *sync.WaitGroup
+	Context  context.Context // cancelled along with the ingestion request
+	Variant  *indexes.Variant
+	Coverage *indexes.Coverage // set instead of 'Variant' for gVCF reference blocks
+	// set instead of 'Variant' for lines that couldn't be ingested
+	Quarantined *indexes.QuarantinedLine
+	WaitGroup   *sync.WaitGroup
+	Progress    *ingest.VariantIngestProgress
+	WriteMode   constants.WriteMode
 }
 
 type GeneIngestionQueueStructure struct {
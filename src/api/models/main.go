@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Config struct {
 	Debug          bool   `yaml:"debug" envconfig:"GOHAN_DEBUG"`
 	SemVer         string `yaml:"semver" envconfig:"GOHAN_SEMVER"`
@@ -14,6 +16,7 @@ type Config struct {
 		FileProcessingConcurrencyLevel int    `yaml:"fileProcessingConcurrencyLevel" envconfig:"GOHAN_API_FILE_PROC_CONC_LVL"`
 		LineProcessingConcurrencyLevel int    `yaml:"lineProcessingConcurrencyLevel" envconfig:"GOHAN_API_LINE_PROC_CONC_LVL"`
 		GtfPath                        string `yaml:"gtfPath" envconfig:"GOHAN_API_GTF_PATH"`
+		ReferencePath                  string `yaml:"referencePath" envconfig:"GOHAN_API_REFERENCE_PATH"`
 		BridgeDirectory                string `yaml:"bridgeDirectory" envconfig:"GOHAN_API_API_DRS_BRIDGE_DIR"`
 	} `yaml:"api"`
 
@@ -21,6 +24,9 @@ type Config struct {
 		Url      string `yaml:"url" envconfig:"GOHAN_ES_URL"`
 		Username string `yaml:"username" envconfig:"GOHAN_ES_USERNAME"`
 		Password string `yaml:"password" envconfig:"GOHAN_ES_PASSWORD"`
+
+		// throttles the deletion of variants (0 = unthrottled)
+		DeletionRequestsPerSecond int `yaml:"deletionRequestsPerSecond" envconfig:"GOHAN_ES_DELETION_RPS"`
 	} `yaml:"elasticsearch"`
 
 	Drs struct {
@@ -28,8 +34,26 @@ type Config struct {
 		Username        string `yaml:"username" envconfig:"GOHAN_DRS_BASIC_AUTH_USERNAME"`
 		Password        string `yaml:"password" envconfig:"GOHAN_DRS_BASIC_AUTH_PASSWORD"`
 		BridgeDirectory string `yaml:"bridgeDirectory" envconfig:"GOHAN_DRS_API_DRS_BRIDGE_DIR"`
+
+		// each request attempt is bound by the timeout; failed attempts are retried
+		// with an exponential backoff starting from the initial backoff
+		Timeout        time.Duration `yaml:"timeout" envconfig:"GOHAN_DRS_TIMEOUT" default:"30s"`
+		MaxRetries     uint64        `yaml:"maxRetries" envconfig:"GOHAN_DRS_MAX_RETRIES" default:"5"`
+		InitialBackoff time.Duration `yaml:"initialBackoff" envconfig:"GOHAN_DRS_INITIAL_BACKOFF" default:"1s"`
 	} `yaml:"drs"`
 
+	// where ingested files (and their indexes) are kept
+	Archive struct {
+		Backend   string `yaml:"backend" envconfig:"GOHAN_ARCHIVE_BACKEND"` // "drs" (default) or "local"
+		LocalPath string `yaml:"localPath" envconfig:"GOHAN_ARCHIVE_LOCAL_PATH"`
+	} `yaml:"archive"`
+
+	Sanitation struct {
+		// local stand-in for the metadata service : a file listing the ids
+		// of the datasets variants may belong to, one per line
+		DatasetsPath string `yaml:"datasetsPath" envconfig:"GOHAN_SANITATION_DATASETS_PATH"`
+	} `yaml:"sanitation"`
+
 	AuthX struct {
 		IsAuthorizationEnabled  bool   `yaml:"isAuthorizationEnabled" envconfig:"GOHAN_AUTHZ_ENABLED"`
 		OidcPublicJwksUrl       string `yaml:"oidcPublicJwksUrl" envconfig:"GOHAN_PUBLIC_AUTHN_JWKS_URL"`
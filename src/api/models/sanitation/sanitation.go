@@ -0,0 +1,54 @@
+package sanitation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type State string
+
+const (
+	Running State = "Running"
+	Done          = "Done"
+	Error         = "Error"
+
+	// Skipped jobs lacked what they needed to run, i.e. configuration
+	Skipped = "Skipped"
+)
+
+// what a run was started by
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+)
+
+// only the first items found by a job are listed in its report
+const MaxReportedDetails = 100
+
+// SanitationRun is the report of a run of the sanitation jobs
+type SanitationRun struct {
+	Id          uuid.UUID    `json:"id"`
+	Trigger     string       `json:"trigger"`
+	State       State        `json:"state"`
+	Jobs        []*JobReport `json:"jobs"`
+	StartedAt   time.Time    `json:"startedAt"`
+	CompletedAt *time.Time   `json:"completedAt,omitempty"`
+}
+
+// JobReport is what a sanitation job found, and removed
+type JobReport struct {
+	Name    string   `json:"name"`
+	State   State    `json:"state"`
+	Message string   `json:"message,omitempty"`
+	Found   int64    `json:"found"`
+	Removed int64    `json:"removed"`
+	Details []string `json:"details,omitempty"` // i.e. document ids, or file names
+}
+
+// AddDetail lists an item found by the job, up to MaxReportedDetails
+func (r *JobReport) AddDetail(detail string) {
+	if len(r.Details) < MaxReportedDetails {
+		r.Details = append(r.Details, detail)
+	}
+}
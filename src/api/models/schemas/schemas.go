@@ -179,6 +179,7 @@ var VARIANT_CALL_SCHEMA Schema = map[string]interface{}{
 				"HOMOZYGOUS_REFERENCE",
 				"HETEROZYGOUS",
 				"HOMOZYGOUS_ALTERNATE",
+				"HETEROZYGOUS_ALTERNATE",
 			},
 			"search": map[string]interface{}{
 				"canNegate": true,
@@ -303,12 +303,14 @@ func GenesIngest(c echo.Context) error {
 
 func GetAllGeneIngestionRequests(c echo.Context) error {
 	fmt.Printf("[%s] - GetAllGeneIngestionRequests hit!\n", time.Now())
-	izMap := c.(*contexts.GohanContext).IngestionService.GeneIngestRequestMap
+	ingestionService := c.(*contexts.GohanContext).IngestionService
 
-	// transform map of it-to-ingestRequests to an array
-	m := make([]*ingest.GeneIngestRequest, 0, len(izMap))
-	for _, val := range izMap {
-		m = append(m, val)
+	// read from the durable store, so that requests
+	// from before the last restart are included
+	m, err := ingestionService.GetAllGeneIngestionRequests()
+	if err != nil {
+		// still respond with what is known in memory
+		fmt.Printf("Failed to read gene ingestion requests from the store: %s\n", err)
 	}
 	return c.JSON(http.StatusOK, m)
 }
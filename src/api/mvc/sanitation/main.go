@@ -0,0 +1,41 @@
+package sanitation
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gohan/api/contexts"
+	"gohan/api/models/dtos/errors"
+	sm "gohan/api/models/sanitation"
+	"gohan/api/services/sanitation"
+
+	"github.com/labstack/echo"
+)
+
+// GetSanitationRuns lists the reports of the sanitation runs, most recent first
+func GetSanitationRuns(c echo.Context) error {
+	fmt.Printf("[%s] - GetSanitationRuns hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	runs, err := gc.SanitationService.GetAllRuns()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+	return c.JSON(http.StatusOK, runs)
+}
+
+// TriggerSanitationRun starts a sanitation run straight away, rather than waiting for the daily one
+func TriggerSanitationRun(c echo.Context) error {
+	fmt.Printf("[%s] - TriggerSanitationRun hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	run, err := gc.SanitationService.StartRun(sm.TriggerManual)
+	if err == sanitation.ErrAlreadyRunning {
+		return c.JSON(http.StatusConflict, errors.CreateSimpleConflict(err.Error()))
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+	return c.JSON(http.StatusAccepted, run)
+}
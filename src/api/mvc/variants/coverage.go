@@ -0,0 +1,92 @@
+package variants
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gohan/api/contexts"
+	rm "gohan/api/models/constants/range-mode"
+	"gohan/api/models/dtos"
+	"gohan/api/models/dtos/errors"
+	"gohan/api/models/indexes"
+	esRepo "gohan/api/repositories/elasticsearch"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+)
+
+// GetVariantCoverage tells whether a sample was called at a position, such that the absence
+// of a variant (the position lies within a gVCF reference block, ingested with 'gvcf=true')
+// can be told apart from the absence of data
+func GetVariantCoverage(c echo.Context) error {
+	fmt.Printf("[%s] - GetVariantCoverage hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+	cfg := gc.Config
+	es := gc.Es7Client
+
+	// contigs are stored without their 'chr' prefix
+	chromosome := strings.ReplaceAll(gc.Chromosome, "chr", "")
+	if chromosome == "" || chromosome == "*" {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing 'chromosome'"))
+	}
+
+	position, positionErr := strconv.Atoi(c.QueryParam("position"))
+	if positionErr != nil || position <= 0 {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing or invalid 'position' - please provide a positive integer"))
+	}
+
+	sampleId := c.QueryParam("id")
+	if sampleId == "" {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing sample 'id'"))
+	}
+
+	datasetString := ""
+	if gc.Dataset != uuid.Nil {
+		datasetString = gc.Dataset.String()
+	}
+
+	// variants spanning the position (deletions starting before it included)
+	docs, countErr := esRepo.CountDocumentsContainerVariantOrSampleIdInPositionRange(cfg, es,
+		chromosome, position, position, rm.OVERLAPS,
+		"", sampleId, datasetString,
+		"", "", []string{}, "", gc.AssemblyId,
+		0, 0, 0, nil, nil) // note : no quality thresholds
+	if countErr != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(countErr.Error()))
+	}
+	variantCount := 0
+	if count, ok := docs["count"].(float64); ok {
+		variantCount = int(count)
+	}
+
+	coverage, coverageErr := esRepo.GetCoverageAtPosition(cfg, es, chromosome, position, sampleId, datasetString, gc.AssemblyId)
+	if coverageErr != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(coverageErr.Error()))
+	}
+
+	response := dtos.CoverageResponseDto{
+		SampleId:     sampleId,
+		Chromosome:   chromosome,
+		Position:     position,
+		AssemblyId:   gc.AssemblyId,
+		VariantCount: variantCount,
+		Coverage:     []indexes.Coverage{},
+	}
+	for _, block := range coverage {
+		response.Coverage = append(response.Coverage, *block)
+	}
+
+	switch {
+	case variantCount > 0:
+		response.Status = dtos.CoverageVariant
+	case len(response.Coverage) > 0:
+		response.Status = dtos.CoverageHomRef
+	default:
+		response.Status = dtos.CoverageNoData
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
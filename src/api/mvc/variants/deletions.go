@@ -0,0 +1,177 @@
+package variants
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gohan/api/contexts"
+	"gohan/api/models"
+	"gohan/api/models/dtos"
+	"gohan/api/models/dtos/errors"
+	esRepo "gohan/api/repositories/elasticsearch"
+
+	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/labstack/echo"
+)
+
+// how long a deletion request waits for its task to complete
+// before responding with the task id alone, to be tracked
+const deletionGracePeriod = 5 * time.Second
+
+// how often a file deletion left running is checked on
+const fileDeletionWatchInterval = 10 * time.Second
+
+// DeleteDatasetFileVariants removes the variants a dataset holds from a given file
+func DeleteDatasetFileVariants(c echo.Context) error {
+	fmt.Printf("[%s] - DeleteDatasetFileVariants hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+	cfg := gc.Config
+	es := gc.Es7Client
+
+	dataset := gc.Dataset.String()
+	// fileIds are URIs (i.e. 'drs://host/id'), hence sent escaped
+	fileId, err := url.PathUnescape(c.Param("fileId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid fileId: %s", err)))
+	}
+
+	taskId, err := esRepo.StartDeletingVariantsByFileId(cfg, es, dataset, fileId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+
+	// the file is only forgotten once its variants are all deleted,
+	// which is checked on in the background until the task completes
+	if err := esRepo.MarkVcfFileDeleting(cfg, es, dataset, fileId, taskId); err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+	go watchFileDeletion(cfg, es, taskId)
+
+	return respondWithDeletionTask(gc, taskId)
+}
+
+// DeleteDatasetSampleVariants removes all the calls of a sample across a dataset
+func DeleteDatasetSampleVariants(c echo.Context) error {
+	fmt.Printf("[%s] - DeleteDatasetSampleVariants hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	taskId, err := esRepo.StartDeletingVariantsBySampleId(gc.Config, gc.Es7Client, gc.Dataset.String(), c.Param("sampleId"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+
+	return respondWithDeletionTask(gc, taskId)
+}
+
+// DeleteDatasetRegionVariants removes the variants of a dataset found on a
+// chromosome, optionally between a lower and an upper bound
+func DeleteDatasetRegionVariants(c echo.Context) error {
+	fmt.Printf("[%s] - DeleteDatasetRegionVariants hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	if gc.Chromosome == "*" {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing 'chromosome' query parameter"))
+	}
+	chromosome := strings.ReplaceAll(gc.Chromosome, "chr", "")
+
+	taskId, err := esRepo.StartDeletingVariantsInRegion(gc.Config, gc.Es7Client, gc.Dataset.String(),
+		chromosome, gc.LowerBound, gc.UpperBound, gc.RangeMode)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+
+	return respondWithDeletionTask(gc, taskId)
+}
+
+// GetVariantDeletionTask reports on a deletion still running, or its outcome
+func GetVariantDeletionTask(c echo.Context) error {
+	fmt.Printf("[%s] - GetVariantDeletionTask hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	taskId := c.Param("taskId")
+	task, err := esRepo.GetDeletionTask(gc.Config, gc.Es7Client, taskId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+	if task == nil {
+		return c.JSON(http.StatusNotFound, errors.CreateSimpleNotFound(fmt.Sprintf("deletion task %s not found", taskId)))
+	}
+	if task.Completed {
+		// i.e. left running across a restart
+		completeFileDeletion(gc.Config, gc.Es7Client, task)
+	}
+
+	return c.JSON(http.StatusOK, task)
+}
+
+// respondWithDeletionTask gives a deletion a few seconds to complete, so that small ones
+// are reported on straight away (200). Larger ones are left running and reported on as
+// they stand (202), to be tracked with GET /variants/deletions/:taskId
+func respondWithDeletionTask(gc *contexts.GohanContext, taskId string) error {
+	var (
+		task     *dtos.DeletionTaskDto
+		err      error
+		deadline = time.Now().Add(deletionGracePeriod)
+	)
+	for {
+		task, err = esRepo.GetDeletionTask(gc.Config, gc.Es7Client, taskId)
+		if err != nil {
+			return gc.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+		}
+		if task == nil {
+			// not registered yet
+			task = &dtos.DeletionTaskDto{TaskId: taskId}
+		}
+		if task.Completed || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	if !task.Completed {
+		return gc.JSON(http.StatusAccepted, task)
+	}
+	fmt.Printf("Deleted %d variants (task %s)\n", task.Deleted, taskId)
+	completeFileDeletion(gc.Config, gc.Es7Client, task)
+	return gc.JSON(http.StatusOK, task)
+}
+
+// watchFileDeletion waits for a file's variants to be deleted, to then forget the file
+func watchFileDeletion(cfg *models.Config, es *elasticsearch.Client, taskId string) {
+	for {
+		time.Sleep(fileDeletionWatchInterval)
+
+		task, err := esRepo.GetDeletionTask(cfg, es, taskId)
+		if err != nil {
+			fmt.Printf("Failed to check on deletion task %s: %s\n", taskId, err)
+			continue
+		}
+		if task == nil {
+			// the file is left marked as being deleted, until the task is looked up again
+			fmt.Printf("Deletion task %s not found\n", taskId)
+			return
+		}
+		if task.Completed {
+			completeFileDeletion(cfg, es, task)
+			return
+		}
+	}
+}
+
+// completeFileDeletion forgets the files whose variants a completed task deleted,
+// or keeps them if it failed to, such that the deletion can be attempted again
+func completeFileDeletion(cfg *models.Config, es *elasticsearch.Client, task *dtos.DeletionTaskDto) {
+	if task.Error != "" {
+		if err := esRepo.UnmarkVcfFilesDeleting(cfg, es, task.TaskId); err != nil {
+			fmt.Printf("Failed to restore the files of deletion task %s: %s\n", task.TaskId, err)
+		}
+		return
+	}
+
+	if err := esRepo.DeleteVcfFilesByDeletionTask(cfg, es, task.TaskId); err != nil {
+		fmt.Printf("Failed to forget the files of deletion task %s: %s\n", task.TaskId, err)
+	}
+}
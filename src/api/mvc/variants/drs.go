@@ -0,0 +1,128 @@
+package variants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gohan/api/contexts"
+	im "gohan/api/models/constants/ingest-mode"
+	drsModels "gohan/api/models/drs"
+	"gohan/api/models/ingest"
+	"gohan/api/services/drs"
+	"gohan/api/services/vcf"
+)
+
+// drsObjectRequestError flags a requested DRS object that can't be ingested, as opposed to DRS failing
+type drsObjectRequestError struct {
+	msg string
+}
+
+func (e *drsObjectRequestError) Error() string {
+	return e.msg
+}
+
+// getDrsObjects resolves the requested DRS objects (ids or DRS URIs), such that
+// unknown ones are rejected before anything gets queued
+func getDrsObjects(ctx context.Context, client *drs.Client, ids []string, authHeader string) ([]*drsModels.Object, error) {
+	objects := make([]*drsModels.Object, 0, len(ids))
+	for _, id := range ids {
+		objectId, ok := client.ObjectId(id)
+		if !ok {
+			return nil, &drsObjectRequestError{fmt.Sprintf("invalid DRS object id %s - please provide ids, or DRS URIs of %s", id, client.Uri("<id>"))}
+		}
+
+		object, err := client.GetObject(ctx, objectId, authHeader)
+		if err != nil {
+			if errors.Is(err, drs.ErrNotFound) {
+				return nil, &drsObjectRequestError{fmt.Sprintf("DRS object %s not found! Aborted -- ", id)}
+			}
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// queueDrsVariantIngestion registers a new ingestion request for a .vcf.gz already held by DRS.
+// Its bytes are streamed from DRS and indexed as they come in; being archived already, it's
+// neither copied to the bridge directory nor ingested into DRS all over again
+func queueDrsVariantIngestion(gc *contexts.GohanContext, object *drsModels.Object, params variantIngestionParameters) ingest.IngestResponseDTO {
+	// unlike their names, DRS URIs tell objects apart
+	return queueIngestionRequest(gc, gc.DrsClient.Uri(object.Id), params,
+		func(ctx context.Context, gc *contexts.GohanContext, reqStat *ingest.VariantIngestRequest, params variantIngestionParameters) {
+			runDrsVariantIngestion(ctx, gc, reqStat, params, object)
+		})
+}
+
+func runDrsVariantIngestion(ctx context.Context, gc *contexts.GohanContext, reqStat *ingest.VariantIngestRequest, params variantIngestionParameters, object *drsModels.Object) {
+	cfg := gc.Config
+	ingestionService := gc.IngestionService
+	client := gc.DrsClient
+
+	fileId := reqStat.Filename
+	reqStat.FileId = fileId
+	fileName := object.Name
+	if fileName == "" {
+		fileName = object.Id
+	}
+
+	fail := func(msg string) {
+		fmt.Println(msg)
+
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
+	}
+
+	if params.options.DryRun {
+		// nothing is indexed, the file is only read
+		source, err := client.Open(ctx, object, params.authHeader)
+		if err != nil {
+			fail(fmt.Sprintf("error opening %s: %s", fileId, err))
+			return
+		}
+		defer source.Close()
+
+		report := validateVcfStream(ctx, gc, source, object.Size, fileName, params.assemblyId, params.options, reqStat.Progress)
+		report.Filename = fileName
+		completeDryRun(ctx, ingestionService, reqStat, report)
+		return
+	}
+
+	// ---   only new samples may be appended to the dataset
+	if params.options.Mode == im.APPEND {
+		source, err := client.Open(ctx, object, params.authHeader)
+		if err != nil {
+			fail(fmt.Sprintf("error opening %s: %s", fileId, err))
+			return
+		}
+		collisions, collisionErr := findSampleCollisionsInStream(gc, source, fileName, params.dataset)
+		source.Close()
+		if collisionErr == nil && len(collisions) > 0 {
+			collisionErr = fmt.Errorf("samples %s are already in dataset %s", strings.Join(collisions, ", "), params.dataset)
+		}
+		if collisionErr != nil {
+			fail(fmt.Sprintf("can't append %s: %s", fileName, collisionErr))
+			return
+		}
+	}
+
+	if wasCancelled(ctx, ingestionService, reqStat, fileName, "before being streamed from DRS for indexing") {
+		return
+	}
+
+	fmt.Printf("Streaming %s from DRS !\n", fileId)
+	source, err := client.Open(ctx, object, params.authHeader)
+	if err != nil {
+		fail(fmt.Sprintf("error opening %s: %s", fileId, err))
+		return
+	}
+	defer source.Close()
+
+	// the tabix index isn't needed to index variants, and DRS objects don't point to theirs
+	indexVariantFile(ctx, gc, reqStat, params, fileName, fileId, "", func(validator *vcf.Validator) (*vcf.Header, error) {
+		return ingestionService.ProcessVcfStream(ctx, reqStat.Id, source, object.Size, fileName, fileId, params.dataset, params.assemblyId, params.options, cfg.Api.LineProcessingConcurrencyLevel, reqStat.Progress, validator)
+	})
+}
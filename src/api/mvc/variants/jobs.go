@@ -0,0 +1,145 @@
+package variants
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"gohan/api/contexts"
+	im "gohan/api/models/constants/ingest-mode"
+	wm "gohan/api/models/constants/write-mode"
+	"gohan/api/models/dtos/errors"
+	"gohan/api/models/ingest"
+	"gohan/api/utils"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// VariantsIngestJob starts ingesting the .vcf.gz files described by a JSON body.
+// When an 'Idempotency-Key' header is provided, resubmitting the same key returns
+// the original job rather than starting the ingestion all over again
+func VariantsIngestJob(c echo.Context) error {
+	fmt.Printf("[%s] - VariantsIngestJob hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	idempotencyKey := strings.TrimSpace(c.Request().Header.Get("Idempotency-Key"))
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("'Idempotency-Key' must not exceed %d characters", maxIdempotencyKeyLength)))
+	}
+
+	var request ingest.VariantIngestJobRequestDTO
+	if err := json.NewDecoder(c.Request().Body).Decode(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid request body: %s", err)))
+	}
+
+	// -- validate
+	if len(request.AssemblyId) == 0 {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing assemblyId"))
+	}
+	if !utils.IsValidUUID(request.Dataset) {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("missing or invalid dataset %s - please provide a valid uuid", request.Dataset)))
+	}
+	dataset := uuid.MustParse(request.Dataset)
+	request.Dataset = dataset.String() // normalized, for comparison with resubmissions
+
+	sources := 0
+	for _, provided := range []bool{len(request.Files) > 0, request.Directory != "", len(request.DrsObjectIds) > 0} {
+		if provided {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("please provide either 'files', a 'directory' or 'drsObjectIds'"))
+	}
+	for _, fileName := range request.Files {
+		if fileName == "" {
+			return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("found an empty file name in 'files'"))
+		}
+	}
+	for _, drsObjectId := range request.DrsObjectIds {
+		if drsObjectId == "" {
+			return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("found an empty id in 'drsObjectIds'"))
+		}
+	}
+	writeMode, err := wm.CastToWriteMode(string(request.Options.WriteMode))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid writeMode %s - please provide 'index' or 'create'", request.Options.WriteMode)))
+	}
+	request.Options.WriteMode = writeMode // normalized, for comparison with resubmissions
+	mode, err := im.CastToIngestMode(string(request.Options.Mode))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid mode %s - please provide 'add', 'replace' or 'append'", request.Options.Mode)))
+	}
+	if mode == im.REPLACE && writeMode == wm.CREATE {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("the '%s' mode requires the '%s' write mode", im.REPLACE, wm.INDEX)))
+	}
+	request.Options.Mode = mode
+
+	// -- submit
+	var resolveErr error
+	job, existing, err := gc.IngestionService.SubmitVariantIngestJob(idempotencyKey, request, func() ([]ingest.IngestResponseDTO, error) {
+		params := variantIngestionParameters{
+			assemblyId:     request.AssemblyId,
+			dataset:        dataset,
+			projectId:      request.Project,
+			datasetId:      request.Dataset,
+			authHeader:     c.Request().Header.Get("Authorization"),
+			options:        request.Options,
+			idempotencyKey: idempotencyKey,
+		}
+
+		if len(request.DrsObjectIds) > 0 {
+			objects, err := getDrsObjects(c.Request().Context(), gc.DrsClient, request.DrsObjectIds, params.authHeader)
+			var requestErr *drsObjectRequestError
+			if stdErrors.As(err, &requestErr) {
+				resolveErr = err
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			fmt.Printf("Ingest Start: %s\n", time.Now())
+
+			responseDtos := []ingest.IngestResponseDTO{}
+			for _, object := range objects {
+				responseDtos = append(responseDtos, queueDrsVariantIngestion(gc, object, params))
+			}
+			return responseDtos, nil
+		}
+
+		fileNames, err := resolveVariantFileNames(gc.Config, request.Files, request.Directory)
+		if err != nil {
+			resolveErr = err
+			return nil, err
+		}
+
+		fmt.Printf("Ingest Start: %s\n", time.Now())
+
+		responseDtos := []ingest.IngestResponseDTO{}
+		for _, fileName := range fileNames {
+			responseDtos = append(responseDtos, queueVariantIngestion(gc, fileName, params))
+		}
+		return responseDtos, nil
+	})
+	if resolveErr != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(resolveErr.Error()))
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+
+	if existing {
+		if !reflect.DeepEqual(job.Request, request) {
+			return c.JSON(http.StatusUnprocessableEntity, errors.CreateSimpleUnprocessableEntity(fmt.Sprintf("'Idempotency-Key' %s was already used for job %s with a different request", idempotencyKey, job.Id)))
+		}
+		return c.JSON(http.StatusOK, job)
+	}
+	return c.JSON(http.StatusCreated, job)
+}
@@ -1,6 +1,8 @@
 package variants
 
 import (
+	"context"
+	stdErrors "errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,11 +13,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"regexp"
 	"time"
 
 	"gohan/api/contexts"
+	"gohan/api/models"
 	s "gohan/api/models/constants/sort"
 	"gohan/api/models/dtos"
 	"gohan/api/models/dtos/errors"
@@ -24,9 +28,16 @@ import (
 	"gohan/api/models/schemas"
 	"gohan/api/mvc"
 	esRepo "gohan/api/repositories/elasticsearch"
+	"gohan/api/services"
+	"gohan/api/services/archive"
+	"gohan/api/services/drs"
 	variantService "gohan/api/services/variants"
+	"gohan/api/services/vcf"
 	"gohan/api/utils"
 
+	im "gohan/api/models/constants/ingest-mode"
+	rm "gohan/api/models/constants/range-mode"
+	wm "gohan/api/models/constants/write-mode"
 	"gohan/api/models/constants/zygosity"
 
 	"github.com/google/uuid"
@@ -98,22 +109,70 @@ func VariantsCountBySampleId(c echo.Context) error {
 	return executeCountByIds(c, expectedSingleSampleIdSlice, false)
 }
 
+// VariantsIngest starts ingesting the requested .vcf.gz files.
+// Deprecated: a retried GET can launch duplicate ingestions, use VariantsIngestJob instead
 func VariantsIngest(c echo.Context) error {
 	fmt.Printf("[%s] - VariantsIngest hit!\n", time.Now())
 	gc := c.(*contexts.GohanContext)
+	c.Response().Header().Set("Deprecation", "true")
+	c.Response().Header().Set("Link", "</variants/ingestion/jobs>; rel=\"successor-version\"")
 
 	cfg := gc.Config
-	vcfPath := cfg.Api.VcfPath
-	drsUrl := cfg.Drs.Url
 
 	// query parameters
 	assemblyId := gc.AssemblyId
 	dataset := gc.Dataset
 
+	// Authz related
+	authHeader := c.Request().Header.Get("Authorization")
+	datasetId := c.QueryParam("dataset")
+	projectId := c.QueryParam("project")
+
+	c.Logger().Debug(authHeader, datasetId)
+
 	// retrieve query parameters (comman separated)
 	var fileNames []string
-	// get vcf files
-	var vcfGzfiles []string
+	dirName := c.QueryParam("directory")
+	if dirName == "" {
+		fileNames = strings.Split(c.QueryParam("fileNames"), ",")
+		for _, fileName := range fileNames {
+			if fileName == "" {
+				// TODO: create a standard response object
+				return c.JSON(http.StatusBadRequest, "{\"error\" : \"Missing 'fileNames' query parameter!\"}")
+			}
+		}
+	}
+
+	fileNames, err := resolveVariantFileNames(cfg, fileNames, dirName)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "{\"error\" : \""+err.Error()+"\"}")
+	}
+
+	options := getVariantIngestOptionsQueryParams(c)
+
+	fmt.Printf("Ingest Start: %s\n", time.Now())
+
+	// ingest vcf
+	responseDtos := []ingest.IngestResponseDTO{}
+	for _, fileName := range fileNames {
+		responseDtos = append(responseDtos, queueVariantIngestion(gc, fileName, variantIngestionParameters{
+			assemblyId: assemblyId,
+			dataset:    dataset,
+			projectId:  projectId,
+			datasetId:  datasetId,
+			authHeader: authHeader,
+			options:    options,
+		}))
+	}
+
+	return c.JSON(http.StatusOK, responseDtos)
+}
+
+// resolveVariantFileNames validates the requested .vcf.gz files, or lists those found
+// in the requested directory, as paths relative to the VCF path. Paths given relative
+// to the DRS bridge directory are accepted as well
+func resolveVariantFileNames(cfg *models.Config, fileNames []string, dirName string) ([]string, error) {
+	vcfPath := cfg.Api.VcfPath
 
 	// helper function
 	accumulatorWalkFunc := func(bucket *[]string) func(absoluteFileName string, info os.FileInfo, err error) error {
@@ -148,284 +207,577 @@ func VariantsIngest(c echo.Context) error {
 	}
 	//
 
-	// Authz related
-	authHeader := c.Request().Header.Get("Authorization")
-	datasetId := c.QueryParam("dataset")
-	projectId := c.QueryParam("project")
-
-	c.Logger().Debug(authHeader, datasetId)
-
-	dirName := c.QueryParam("directory")
-	if dirName != "" {
-		if strings.HasPrefix(dirName, cfg.Drs.BridgeDirectory) {
-			replaced := strings.Replace(dirName, cfg.Drs.BridgeDirectory, "", 1)
+	// remove DRS bridge directory base path from the requested name (if present)
+	stripBridgeDirectory := func(name string) string {
+		if !strings.HasPrefix(name, cfg.Drs.BridgeDirectory) {
+			return name
+		}
+		replaced := strings.Replace(name, cfg.Drs.BridgeDirectory, "", 1)
 
-			replacedFullPath, replacedDirName := path.Split(replaced)
-			// strip the leading '/' away
-			if replacedFullPath == "/" {
-				dirName = replacedDirName
-			} else {
-				dirName = replaced
-			}
+		replacedDirectory, replacedName := path.Split(replaced)
+		// strip the leading '/' away
+		if replacedDirectory == "/" {
+			return replacedName
 		}
+		return replaced
+	}
 
-		err := filepath.Walk(fmt.Sprintf("%s/%s", vcfPath, dirName), accumulatorWalkFunc(&fileNames))
+	if dirName != "" {
+		var found []string
+		err := filepath.Walk(fmt.Sprintf("%s/%s", vcfPath, stripBridgeDirectory(dirName)), accumulatorWalkFunc(&found))
 		if err != nil {
 			log.Println(err)
 		}
-	} else {
-		fileNames = strings.Split(c.QueryParam("fileNames"), ",")
-		for i, fileName := range fileNames {
-			if fileName == "" {
-				// TODO: create a standard response object
-				return c.JSON(http.StatusBadRequest, "{\"error\" : \"Missing 'fileNames' query parameter!\"}")
-			} else {
-				// remove DRS bridge directory base path from the requested filenames (if present)
-				if strings.HasPrefix(fileName, cfg.Drs.BridgeDirectory) {
-					replaced := strings.Replace(fileName, cfg.Drs.BridgeDirectory, "", 1)
-
-					replacedDirectory, replacedFileName := path.Split(replaced)
-					// strip the leading '/' away
-					if replacedDirectory == "/" {
-						fileNames[i] = replacedFileName
-					} else {
-						fileNames[i] = replaced
-					}
-				}
+		return found, nil
+	}
+
+	resolved := make([]string, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		resolved = append(resolved, stripBridgeDirectory(fileName))
+	}
+
+	// TODO: simply load files by filename provided
+	// rather than load all available files and looping over them
+	// -----
+	// Read all files and temporarily catalog all .vcf.gz files
+	var vcfGzfiles []string
+	err := filepath.Walk(vcfPath, accumulatorWalkFunc(&vcfGzfiles))
+	if err != nil {
+		log.Println(err)
+	}
+
+	// Locate fileName from request inside found files
+	for _, fileName := range resolved {
+		if !utils.StringInSlice(fileName, vcfGzfiles) {
+			return nil, fmt.Errorf("file %s not found! Aborted -- ", fileName)
+		}
+	}
+	// -----
+
+	return resolved, nil
+}
+
+// getVariantIngestOptionsQueryParams reads the ingestion options
+// from the query parameters of the legacy ingestion endpoints
+func getVariantIngestOptionsQueryParams(c echo.Context) ingest.VariantIngestOptions {
+	options := ingest.VariantIngestOptions{}
+
+	// -- optional flags, all defaulting to 'false'
+	for name, option := range map[string]*bool{
+		"filterOutReferences": &options.FilterOutReferences,
+		"gvcf":                &options.Gvcf,
+		"decompose":           &options.Decompose,
+		"normalize":           &options.Normalize,
+		"leftAlign":           &options.LeftAlign,
+		"dryRun":              &options.DryRun,
+	} {
+		qp := c.QueryParam(name)
+		if len(qp) > 0 {
+			var parseErr error
+			*option, parseErr = strconv.ParseBool(qp)
+			if parseErr != nil {
+				fmt.Printf("Error parsing %s: %s, [%s] - defaulting to 'false'\n", name, qp, parseErr)
 			}
 		}
+	}
 
-		// TODO: simply load files by filename provided
-		// rather than load all available files and looping over them
-		// -----
-		// Read all files and temporarily catalog all .vcf.gz files
-		err := filepath.Walk(vcfPath, accumulatorWalkFunc(&vcfGzfiles))
-		if err != nil {
-			log.Println(err)
+	if qp := c.QueryParam("maxErrors"); len(qp) > 0 {
+		maxErrors, parseErr := strconv.Atoi(qp)
+		if parseErr != nil || maxErrors < 0 {
+			fmt.Printf("Error parsing maxErrors: %s, [%v] - defaulting to no limit\n", qp, parseErr)
+		} else {
+			options.MaxErrors = maxErrors
 		}
+	}
 
-		// Locate fileName from request inside found files
-		for _, fileName := range fileNames {
-			if !utils.StringInSlice(fileName, vcfGzfiles) {
-				return c.JSON(http.StatusBadRequest, "{\"error\" : \"file "+fileName+" not found! Aborted -- \"}")
-			}
+	writeMode, parseErr := wm.CastToWriteMode(c.QueryParam("writeMode"))
+	if parseErr != nil {
+		fmt.Printf("Error parsing writeMode: %s, [%s] - defaulting to '%s'\n", c.QueryParam("writeMode"), parseErr, writeMode)
+	}
+	options.WriteMode = writeMode
+
+	mode, parseErr := im.CastToIngestMode(c.QueryParam("mode"))
+	if parseErr != nil {
+		fmt.Printf("Error parsing mode: %s, [%s] - defaulting to '%s'\n", c.QueryParam("mode"), parseErr, mode)
+	}
+	options.Mode = mode
+	if mode == im.REPLACE && writeMode == wm.CREATE {
+		// calls kept as they were would be deleted along with the prior version of the file
+		fmt.Printf("The '%s' mode requires the '%s' write mode - defaulting to it\n", im.REPLACE, wm.INDEX)
+		options.WriteMode = wm.INDEX
+	}
+
+	return options
+}
+
+// variantIngestionParameters gathers everything needed to run a single
+// .vcf.gz through tabix generation, archiving and elasticsearch indexing
+type variantIngestionParameters struct {
+	assemblyId           string
+	dataset              uuid.UUID
+	projectId            string
+	datasetId            string
+	authHeader           string
+	options              ingest.VariantIngestOptions
+	idempotencyKey       string
+	removeSourceWhenDone bool
+}
+
+// queueVariantIngestion registers a new ingestion request for a .vcf.gz file
+// (relative to the configured VCF path) and runs it in the background
+func queueVariantIngestion(gc *contexts.GohanContext, fileName string, params variantIngestionParameters) ingest.IngestResponseDTO {
+	return queueIngestionRequest(gc, fileName, params, runVariantIngestion)
+}
+
+// queueIngestionRequest registers a new ingestion request, and has 'run' carry it
+// out in the background once a spot in the file ingestion queue is free
+func queueIngestionRequest(gc *contexts.GohanContext, fileName string, params variantIngestionParameters,
+	run func(ctx context.Context, gc *contexts.GohanContext, reqStat *ingest.VariantIngestRequest, params variantIngestionParameters)) ingest.IngestResponseDTO {
+	ingestionService := gc.IngestionService
+
+	// check if there is an already existing ingestion request state
+	if ingestionService.FilenameAlreadyRunning(fileName) {
+		return ingest.IngestResponseDTO{
+			Filename: fileName,
+			State:    ingest.Error,
+			Message:  "File already being ingested..",
 		}
-		// -----
 	}
 
-	// -- optional filter
-	var (
-		filterOutReferences bool = false // default
-		fohrErr             error
-	)
-	filterOutReferencesQP := c.QueryParam("filterOutReferences")
-	if len(filterOutReferencesQP) > 0 {
-		filterOutReferences, fohrErr = strconv.ParseBool(filterOutReferencesQP)
-		if fohrErr != nil {
-			fmt.Printf("Error parsing filterOutReferences: %s, [%s] - defaulting to 'false'\n", filterOutReferencesQP, fohrErr)
-			// defaults to false
+	// if not, execute
+
+	newRequestState := &ingest.VariantIngestRequest{
+		Id:        uuid.New(),
+		Filename:  fileName,
+		State:     ingest.Queued,
+		CreatedAt: fmt.Sprintf("%v", time.Now()),
+		Progress:  &ingest.VariantIngestProgress{},
+
+		IdempotencyKey: params.idempotencyKey,
+	}
+	ingestionService.IngestRequestChan <- newRequestState
+
+	responseDto := ingest.IngestResponseDTO{
+		Id:       newRequestState.Id,
+		Filename: newRequestState.Filename,
+		State:    newRequestState.State,
+		Message:  "Successfully queued..",
+	}
+
+	// the ingestion request can be cancelled through this context
+	ctx := ingestionService.NewVariantIngestionContext(newRequestState.Id)
+
+	go func(_fileName string, _newRequestState *ingest.VariantIngestRequest) {
+
+		// take a spot in the queue, unless cancelled while waiting
+		select {
+		case ingestionService.ConcurrentFileIngestionQueue <- true:
+		case <-ctx.Done():
+			fmt.Printf("Cancelled %s before it started running\n", _fileName)
+			_newRequestState.State = ingest.Cancelled
+			_newRequestState.Message = "Cancelled while queued"
+			ingestionService.IngestRequestChan <- _newRequestState
+			ingestionService.ReleaseVariantIngestionContext(_newRequestState.Id)
+			return
 		}
+		go func(reqStat *ingest.VariantIngestRequest) {
+			// free up a spot in the queue
+			defer func() {
+				<-ingestionService.ConcurrentFileIngestionQueue
+				ingestionService.ReleaseVariantIngestionContext(reqStat.Id)
+			}()
+
+			fmt.Printf("Begin running %s !\n", reqStat.Filename)
+			reqStat.State = ingest.Running
+			ingestionService.IngestRequestChan <- reqStat
+
+			run(ctx, gc, reqStat, params)
+		}(_newRequestState)
+	}(fileName, newRequestState)
+
+	return responseDto
+}
+
+// wasCancelled stops an ingestion request between steps, if it was cancelled,
+// telling which step was reached (i.e. "once archived, before indexing began")
+func wasCancelled(ctx context.Context, ingestionService *services.IngestionService, reqStat *ingest.VariantIngestRequest, fileName string, step string) bool {
+	if ctx.Err() == nil {
+		return false
 	}
+	fmt.Printf("Ingestion of %s cancelled %s\n", fileName, step)
+	reqStat.State = ingest.Cancelled
+	reqStat.Message = "Cancelled " + step
+	ingestionService.IngestRequestChan <- reqStat
+	return true
+}
 
-	startTime := time.Now()
-	fmt.Printf("Ingest Start: %s\n", startTime)
+// runVariantIngestion indexes a .vcf.gz of the VCF path, once indexed with tabix and archived
+func runVariantIngestion(ctx context.Context, gc *contexts.GohanContext, reqStat *ingest.VariantIngestRequest, params variantIngestionParameters) {
+	cfg := gc.Config
+	vcfPath := cfg.Api.VcfPath
+	ingestionService := gc.IngestionService
+	fileArchive := gc.FileArchive
+	gzippedFileName := reqStat.Filename
 
-	// ingest vcf
-	var (
-		ingestionService = gc.IngestionService
-		responseDtos     = []ingest.IngestResponseDTO{}
-	)
-	for _, fileName := range fileNames {
+	// ---	 open vcf.gz
+
+	fmt.Printf("Opening %s !\n", gzippedFileName)
+	var separator string
+	if strings.HasPrefix(gzippedFileName, "/") {
+		separator = ""
+	} else {
+		separator = "/"
+	}
+
+	gzippedFilePath := fmt.Sprintf("%s%s%s", vcfPath, separator, gzippedFileName)
 
-		// check if there is an already existing ingestion request state
-		if ingestionService.FilenameAlreadyRunning(fileName) {
-			responseDtos = append(responseDtos, ingest.IngestResponseDTO{
-				Filename: fileName,
-				State:    ingest.Error,
-				Message:  "File already being ingested..",
-			})
-			continue
+	// the source file is owned by gohan (i.e. uploaded), and is no longer needed afterwards
+	removeSource := func() {
+		if !params.removeSourceWhenDone {
+			return
 		}
+		os.Remove(gzippedFilePath)
+		if sourceDir := path.Dir(gzippedFilePath); sourceDir != path.Clean(vcfPath) {
+			os.Remove(sourceDir) // only succeeds if the directory is empty
+		}
+	}
+	// whether the ingestion succeeds or not
+	defer removeSource()
+
+	if params.options.DryRun {
+		// nothing is archived nor indexed, the file is only read
+		report := validateVcf(ctx, gc, gzippedFilePath, params.assemblyId, params.options)
+		report.Filename = path.Base(gzippedFileName)
+		completeDryRun(ctx, ingestionService, reqStat, report)
+		return
+	}
+
+	r, err := os.Open(gzippedFilePath)
+	if err != nil {
+		msg := fmt.Sprintf("error opening %s: %s\n", gzippedFileName, err)
+		fmt.Println(msg)
+
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
 
-		// if not, execute
+		return
+	}
 
-		newRequestState := &ingest.VariantIngestRequest{
-			Id:        uuid.New(),
-			Filename:  fileName,
-			State:     ingest.Queued,
-			CreatedAt: fmt.Sprintf("%v", startTime),
+	// ---   only new samples may be appended to the dataset
+	if params.options.Mode == im.APPEND {
+		collisions, collisionErr := findSampleCollisions(gc, gzippedFilePath, params.dataset)
+		if collisionErr == nil && len(collisions) > 0 {
+			collisionErr = fmt.Errorf("samples %s are already in dataset %s", strings.Join(collisions, ", "), params.dataset)
 		}
-		ingestionService.IngestRequestChan <- newRequestState
+		if collisionErr != nil {
+			msg := fmt.Sprintf("can't append %s: %s", gzippedFileName, collisionErr)
+			fmt.Println(msg)
 
-		responseDtos = append(responseDtos, ingest.IngestResponseDTO{
-			Id:       newRequestState.Id,
-			Filename: newRequestState.Filename,
-			State:    newRequestState.State,
-			Message:  "Successfully queued..",
-		})
+			reqStat.State = ingest.Error
+			reqStat.Message = msg
+			ingestionService.IngestRequestChan <- reqStat
 
-		go func(_fileName string, _newRequestState *ingest.VariantIngestRequest) {
+			r.Close()
+			return
+		}
+	}
 
-			// take a spot in the queue
-			ingestionService.ConcurrentFileIngestionQueue <- true
-			go func(gzippedFileName string, reqStat *ingest.VariantIngestRequest) {
-				// free up a spot in the queue
-				defer func() {
-					<-ingestionService.ConcurrentFileIngestionQueue
-				}()
+	// ---   copy gzipped file over to a temp folder that is common to DRS and gohan
+	// 	     such that DRS can load the file into memory to process rather than receiving
+	//       the file from an upload, thus utilizing it's already-exisiting /private/ingest endpoind
+	// -----
+	tmpDestinationFileName := fmt.Sprintf("%s%s%s", cfg.Api.BridgeDirectory, separator, gzippedFileName)
+
+	// prepare directory inside bridge directory
+	partialTmpDir, _ := path.Split(gzippedFileName)
+	fullTmpDir, _ := path.Split(tmpDestinationFileName)
+	if partialTmpDir != "" {
+		if _, err := os.Stat(fullTmpDir); os.IsNotExist(err) {
+			os.MkdirAll(fullTmpDir, 0700) // Create your file
+		}
+	}
 
-				fmt.Printf("Begin running %s !\n", gzippedFileName)
-				reqStat.State = ingest.Running
-				ingestionService.IngestRequestChan <- reqStat
+	destination, err := os.Create(tmpDestinationFileName)
+	if err != nil {
+		msg := fmt.Sprintf("error creating temporary bridge file for %s: %s\n", gzippedFileName, err)
+		fmt.Println(msg)
 
-				// ---	 open vcf.gz
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
 
-				fmt.Printf("Opening %s !\n", gzippedFileName)
-				var separator string
-				if strings.HasPrefix(gzippedFileName, "/") {
-					separator = ""
-				} else {
-					separator = "/"
-				}
+		return
+	}
+	defer destination.Close()
 
-				gzippedFilePath := fmt.Sprintf("%s%s%s", vcfPath, separator, gzippedFileName)
-				r, err := os.Open(gzippedFilePath)
-				if err != nil {
-					msg := fmt.Sprintf("error opening %s: %s\n", gzippedFileName, err)
-					fmt.Println(msg)
+	_, err = io.Copy(destination, r)
+	if err != nil {
+		msg := fmt.Sprintf("error copying to temporary bridge file from %s to %s: %s\n", gzippedFileName, tmpDestinationFileName, err)
+		fmt.Println(msg)
 
-					reqStat.State = ingest.Error
-					reqStat.Message = msg
-					ingestionService.IngestRequestChan <- reqStat
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
 
-					return
-				}
+		return
+	}
+	// -----
 
-				// ---   copy gzipped file over to a temp folder that is common to DRS and gohan
-				// 	     such that DRS can load the file into memory to process rather than receiving
-				//       the file from an upload, thus utilizing it's already-exisiting /private/ingest endpoind
-				// -----
-				tmpDestinationFileName := fmt.Sprintf("%s%s%s", cfg.Api.BridgeDirectory, separator, gzippedFileName)
-
-				// prepare directory inside bridge directory
-				partialTmpDir, _ := path.Split(gzippedFileName)
-				fullTmpDir, _ := path.Split(tmpDestinationFileName)
-				if partialTmpDir != "" {
-					if _, err := os.Stat(fullTmpDir); os.IsNotExist(err) {
-						os.MkdirAll(fullTmpDir, 0700) // Create your file
-					}
-				}
+	// --- tabix generation
+	fmt.Printf("Generating Tabix %s !\n", tmpDestinationFileName)
+	tabixFileDir, tabixFileName, tabixErr := ingestionService.GenerateTabix(tmpDestinationFileName)
+	if tabixErr != nil {
+		msg := fmt.Sprintf("Something went wrong: %s", tabixErr)
+		fmt.Println(msg)
 
-				destination, err := os.Create(tmpDestinationFileName)
-				if err != nil {
-					msg := fmt.Sprintf("error creating temporary bridge file for %s: %s\n", gzippedFileName, err)
-					fmt.Println(msg)
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
 
-					reqStat.State = ingest.Error
-					reqStat.Message = msg
-					ingestionService.IngestRequestChan <- reqStat
+		return
+	}
+	tabixFileNameWithRelativePath := fmt.Sprintf("%s%s", partialTmpDir, tabixFileName)
 
-					return
-				}
-				defer destination.Close()
+	if wasCancelled(ctx, ingestionService, reqStat, gzippedFileName, "once indexed with tabix, before being archived") {
+		return
+	}
 
-				_, err = io.Copy(destination, r)
-				if err != nil {
-					msg := fmt.Sprintf("error copying to temporary bridge file from %s to %s: %s\n", gzippedFileName, tmpDestinationFileName, err)
-					fmt.Println(msg)
+	// ---   archive the compressed file, then its index
+	fmt.Printf("Archiving %s !\n", gzippedFileName)
+	archivedFileId, archiveErr := fileArchive.Store(ctx, archive.StoreRequest{
+		FileName:   gzippedFileName,
+		ProjectId:  params.projectId,
+		DatasetId:  params.datasetId,
+		AuthHeader: params.authHeader,
+	})
+	if archiveErr != nil {
+		msg := fmt.Sprintf("Something went wrong: failed to archive %s: %s", gzippedFileName, archiveErr)
+		fmt.Println(msg)
 
-					reqStat.State = ingest.Error
-					reqStat.Message = msg
-					ingestionService.IngestRequestChan <- reqStat
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
 
-					return
-				}
-				// -----
+		return
+	}
+	reqStat.FileId = archivedFileId
+
+	fmt.Printf("Archiving %s !\n", tabixFileNameWithRelativePath)
+	archivedTabixFileId, archiveErr := fileArchive.Store(ctx, archive.StoreRequest{
+		FileName:   tabixFileNameWithRelativePath,
+		ProjectId:  params.projectId,
+		DatasetId:  params.datasetId,
+		AuthHeader: params.authHeader,
+	})
+	if archiveErr != nil {
+		msg := fmt.Sprintf("Something went wrong: failed to archive %s: %s", tabixFileNameWithRelativePath, archiveErr)
+		fmt.Println(msg)
 
-				// --- tabix generation
-				fmt.Printf("Generating Tabix %s !\n", tmpDestinationFileName)
-				tabixFileDir, tabixFileName, tabixErr := ingestionService.GenerateTabix(tmpDestinationFileName)
-				if tabixErr != nil {
-					msg := "Something went wrong: Tabix problem " + gzippedFileName
-					fmt.Println(msg)
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
 
-					reqStat.State = ingest.Error
-					reqStat.Message = msg
-					ingestionService.IngestRequestChan <- reqStat
+		return
+	}
 
-					return
-				}
-				tabixFileNameWithRelativePath := fmt.Sprintf("%s%s", partialTmpDir, tabixFileName)
+	// ---   remove temporary files now that they have been archived successfully
+	fmt.Printf("Removing %s !\n", tmpDestinationFileName)
+	if tmpFileRemovalErr := os.Remove(tmpDestinationFileName); tmpFileRemovalErr != nil {
+		msg := fmt.Sprintf("Something went wrong: trying to remove temporary file at %s : %s\n", tmpDestinationFileName, tmpFileRemovalErr)
+		fmt.Println(msg)
 
-				// ---   push compressed to DRS
-				fmt.Printf("Uploading %s to DRS !\n", gzippedFileName)
-				drsFileId := ingestionService.UploadVcfGzToDrs(cfg, cfg.Drs.BridgeDirectory, gzippedFileName, drsUrl, projectId, datasetId, authHeader)
-				if drsFileId == "" {
-					msg := "Something went wrong: DRS File Id is empty for " + gzippedFileName
-					fmt.Println(msg)
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
 
-					reqStat.State = ingest.Error
-					reqStat.Message = msg
-					ingestionService.IngestRequestChan <- reqStat
+		return
+	}
+	tmpTabixFilePath := fmt.Sprintf("%s%s", tabixFileDir, tabixFileName)
+	fmt.Printf("Removing %s !\n", tmpTabixFilePath)
+	if tmpTabixFileRemovalErr := os.Remove(tmpTabixFilePath); tmpTabixFileRemovalErr != nil {
+		msg := fmt.Sprintf("Something went wrong: trying to remove temporary file at %s : %s\n", tmpTabixFilePath, tmpTabixFileRemovalErr)
+		fmt.Println(msg)
 
-					return
-				}
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
 
-				// -- push tabix to DRS
-				fmt.Printf("Uploading %s to DRS !\n", tabixFileNameWithRelativePath)
-				drsTabixFileId := ingestionService.UploadVcfGzToDrs(cfg, cfg.Drs.BridgeDirectory, tabixFileNameWithRelativePath, drsUrl, projectId, datasetId, authHeader)
-				if drsTabixFileId == "" {
-					msg := "Something went wrong: DRS Tabix File Id is empty for " + tabixFileNameWithRelativePath
-					fmt.Println(msg)
+		return
+	}
 
-					reqStat.State = ingest.Error
-					reqStat.Message = msg
-					ingestionService.IngestRequestChan <- reqStat
+	defer r.Close()
 
-					return
-				}
+	if wasCancelled(ctx, ingestionService, reqStat, gzippedFileName, "once archived, before indexing began") {
+		return
+	}
 
-				// ---   remove temporary files now that they have been ingested successfully into DRS
-				fmt.Printf("Removing %s !\n", tmpDestinationFileName)
-				if tmpFileRemovalErr := os.Remove(tmpDestinationFileName); tmpFileRemovalErr != nil {
-					msg := fmt.Sprintf("Something went wrong: trying to remove temporary file at %s : %s\n", tmpDestinationFileName, tmpFileRemovalErr)
-					fmt.Println(msg)
+	indexVariantFile(ctx, gc, reqStat, params, gzippedFileName, archivedFileId, archivedTabixFileId, func(validator *vcf.Validator) (*vcf.Header, error) {
+		return ingestionService.ProcessVcf(ctx, reqStat.Id, gzippedFilePath, archivedFileId, params.dataset, params.assemblyId, params.options, cfg.Api.LineProcessingConcurrencyLevel, reqStat.Progress, validator)
+	})
+}
 
-					reqStat.State = ingest.Error
-					reqStat.Message = msg
-					ingestionService.IngestRequestChan <- reqStat
+// indexVariantFile indexes the variants of a .vcf.gz through 'process', then keeps track of the
+// file they came from and, in the 'replace' mode, removes those its prior versions brought
+func indexVariantFile(ctx context.Context, gc *contexts.GohanContext, reqStat *ingest.VariantIngestRequest, params variantIngestionParameters,
+	gzippedFileName string, archivedFileId string, archivedTabixFileId string,
+	process func(validator *vcf.Validator) (*vcf.Header, error)) {
+	cfg := gc.Config
+	ingestionService := gc.IngestionService
 
-					return
-				}
-				tmpTabixFilePath := fmt.Sprintf("%s%s", tabixFileDir, tabixFileName)
-				fmt.Printf("Removing %s !\n", tmpTabixFilePath)
-				if tmpTabixFileRemovalErr := os.Remove(tmpTabixFilePath); tmpTabixFileRemovalErr != nil {
-					msg := fmt.Sprintf("Something went wrong: trying to remove temporary file at %s : %s\n", tmpTabixFilePath, tmpTabixFileRemovalErr)
-					fmt.Println(msg)
+	// ---	 load vcf into memory and ingest the vcf file into elasticsearch
+	beginProcessingTime := time.Now()
+	fmt.Printf("Begin processing %s at [%s]\n", gzippedFileName, beginProcessingTime)
+	validator := vcf.NewValidator(path.Base(gzippedFileName))
+	vcfHeader, processErr := process(validator)
+	fmt.Printf("Ingest duration for file at %s : %s\n", gzippedFileName, time.Since(beginProcessingTime))
 
-					reqStat.State = ingest.Error
-					reqStat.Message = msg
-					ingestionService.IngestRequestChan <- reqStat
+	if processErr != nil {
+		validator.LineError(0, processErr.Error())
+	}
+	report := validator.Report()
+	reqStat.Validation = &report
 
-					return
-				}
+	// helper to keep track of the file the indexed variants came from
+	saveVcfFile := func() {
+		if vcfHeader == nil {
+			return
+		}
+		vcfFile := &indexes.VcfFile{
+			FileId:             archivedFileId,
+			TabixFileId:        archivedTabixFileId,
+			Filename:           path.Base(gzippedFileName),
+			Dataset:            params.dataset.String(),
+			Project:            params.projectId,
+			AssemblyId:         params.assemblyId,
+			SampleIds:          vcfHeader.SampleIds,
+			Header:             vcfHeader.Document(),
+			IngestionRequestId: reqStat.Id.String(),
+			IngestionOptions:   params.options,
+			CreatedTime:        beginProcessingTime,
+			IngestedTime:       time.Now(),
+		}
+		if err := esRepo.SaveVcfFile(cfg, gc.Es7Client, vcfFile); err != nil {
+			fmt.Printf("Failed to save the header of %s: %s\n", gzippedFileName, err)
+		}
+	}
 
-				defer r.Close()
+	if ctx.Err() != nil {
+		// ProcessVcf has returned, so no more documents from this file are on their way
+		reqStat.State = ingest.Cancelled
+		reqStat.Message = "Cancelled during indexing"
 
-				// ---	 load vcf into memory and ingest the vcf file into elasticsearch
-				beginProcessingTime := time.Now()
-				fmt.Printf("Begin processing %s at [%s]\n", gzippedFilePath, beginProcessingTime)
-				ingestionService.ProcessVcf(gzippedFilePath, drsFileId, dataset, assemblyId, filterOutReferences, cfg.Api.LineProcessingConcurrencyLevel)
-				fmt.Printf("Ingest duration for file at %s : %s\n", gzippedFilePath, time.Since(beginProcessingTime))
+		if ingestionService.IsVariantIngestionRollbackRequested(reqStat.Id) {
+			deleteResponse, delErr := esRepo.DeleteVariantsByFileId(cfg, gc.Es7Client, params.dataset.String(), archivedFileId)
+			if delErr != nil {
+				reqStat.Message = fmt.Sprintf("Cancelled during indexing, but failed to roll back documents with fileId %s: %s", archivedFileId, delErr)
+			} else {
+				reqStat.Message = fmt.Sprintf("Cancelled during indexing, and rolled back %v documents", deleteResponse["deleted"])
+			}
+		} else {
+			// some of its variants were indexed
+			saveVcfFile()
+		}
+
+		ingestionService.IngestRequestChan <- reqStat
+		return
+	}
+
+	saveVcfFile()
 
-				reqStat.State = ingest.Done
-				ingestionService.IngestRequestChan <- reqStat
-			}(_fileName, _newRequestState)
-		}(fileName, newRequestState)
+	if processErr != nil {
+		msg := fmt.Sprintf("error reading %s: %s", gzippedFileName, processErr)
+		fmt.Println(msg)
 
+		reqStat.State = ingest.Error
+		reqStat.Message = msg
+		ingestionService.IngestRequestChan <- reqStat
+
+		return
 	}
 
-	return c.JSON(http.StatusOK, responseDtos)
+	// ---   remove what prior versions of the file brought, now that the new one is in
+	if params.options.Mode == im.REPLACE {
+		deleted, replaceErr := replacePriorVariants(gc, gzippedFileName, archivedFileId, params.dataset, beginProcessingTime)
+		if replaceErr != nil {
+			msg := fmt.Sprintf("ingested %s, but failed to remove the variants of its prior versions: %s", gzippedFileName, replaceErr)
+			fmt.Println(msg)
+
+			reqStat.State = ingest.Error
+			reqStat.Message = msg
+			ingestionService.IngestRequestChan <- reqStat
+
+			return
+		}
+		reqStat.Message = fmt.Sprintf("Replaced prior versions of %s, removing %d documents", path.Base(gzippedFileName), deleted)
+	}
+
+	if mismatches := atomic.LoadInt64(&reqStat.Progress.RefMismatches); mismatches > 0 {
+		reqStat.Message = fmt.Sprintf("%d of %d REF alleles don't match the %s reference genome", mismatches, atomic.LoadInt64(&reqStat.Progress.RefChecked), params.assemblyId)
+	}
+
+	reqStat.State = ingest.Done
+	ingestionService.IngestRequestChan <- reqStat
+}
+
+// how long listing files may wait for DRS
+const drsResolutionTimeout = 10 * time.Second
+
+// GetDatasetFiles lists the .vcf.gz files ingested into a dataset, along with their headers
+// and, for those archived in DRS, their DRS objects (i.e. checksums and access methods)
+func GetDatasetFiles(c echo.Context) error {
+	gc := c.(*contexts.GohanContext)
+	dataset := gc.Dataset
+	fmt.Printf("[%s] - GetDatasetFiles hit: [%s]!\n", time.Now(), dataset.String())
+
+	files, err := esRepo.GetVcfFilesByDataset(gc.Config, gc.Es7Client, dataset.String())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+
+	// DRS being unreachable shouldn't prevent the files from being listed
+	ctx, cancel := context.WithTimeout(c.Request().Context(), drsResolutionTimeout)
+	defer cancel()
+	authHeader := c.Request().Header.Get("Authorization")
+
+	results := make([]dtos.VcfFileDto, len(files))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		results[i].VcfFile = file
+
+		wg.Add(1)
+		go func(result *dtos.VcfFileDto) {
+			defer wg.Done()
+			resolveDrsObjects(ctx, gc.DrsClient, result, authHeader)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func resolveDrsObjects(ctx context.Context, client *drs.Client, result *dtos.VcfFileDto, authHeader string) {
+	fileObjectId, ok := client.ObjectId(result.FileId)
+	if !ok {
+		// archived elsewhere
+		return
+	}
+
+	object, err := client.GetObject(ctx, fileObjectId, authHeader)
+	if err != nil {
+		result.DrsError = err.Error()
+		return
+	}
+	result.DrsObject = object
+
+	if tabixObjectId, ok := client.ObjectId(result.TabixFileId); ok {
+		tabixObject, err := client.GetObject(ctx, tabixObjectId, authHeader)
+		if err != nil {
+			result.DrsError = err.Error()
+			return
+		}
+		result.TabixDrsObject = tabixObject
+	}
 }
 
 func GetVariantsOverview(c echo.Context) error {
@@ -448,16 +800,91 @@ func GetVariantsOverview(c echo.Context) error {
 
 func GetAllVariantIngestionRequests(c echo.Context) error {
 	fmt.Printf("[%s] - GetAllVariantIngestionRequests hit!\n", time.Now())
-	izMap := c.(*contexts.GohanContext).IngestionService.IngestRequestMap
+	ingestionService := c.(*contexts.GohanContext).IngestionService
 
-	// transform map of it-to-ingestRequests to an array
-	m := make([]*ingest.VariantIngestRequest, 0, len(izMap))
-	for _, val := range izMap {
-		m = append(m, val)
+	// read from the durable store, so that requests
+	// from before the last restart are included
+	m, err := ingestionService.GetAllVariantIngestionRequests()
+	if err != nil {
+		// still respond with what is known in memory
+		fmt.Printf("Failed to read variant ingestion requests from the store: %s\n", err)
 	}
 	return c.JSON(http.StatusOK, m)
 }
 
+func CancelVariantIngestionRequest(c echo.Context) error {
+	fmt.Printf("[%s] - CancelVariantIngestionRequest hit!\n", time.Now())
+	ingestionService := c.(*contexts.GohanContext).IngestionService
+
+	requestId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid ingestion request id %s - please provide a valid uuid", c.Param("id"))))
+	}
+
+	// optionally delete what has already been indexed
+	rollback := false
+	if rollbackQP := c.QueryParam("rollback"); len(rollbackQP) > 0 {
+		if rollback, err = strconv.ParseBool(rollbackQP); err != nil {
+			return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid rollback value %s", rollbackQP)))
+		}
+	}
+
+	request, cancelErr := ingestionService.CancelVariantIngestion(requestId, rollback)
+	if request == nil {
+		return c.JSON(http.StatusNotFound, errors.CreateSimpleNotFound(cancelErr.Error()))
+	}
+	if stdErrors.Is(cancelErr, services.ErrNotCancellable) {
+		// already Done, in Error or Cancelled
+		return c.JSON(http.StatusConflict, errors.CreateSimpleConflict(cancelErr.Error()))
+	}
+	if cancelErr != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(cancelErr.Error()))
+	}
+
+	// the request's state moves to 'Cancelled' once the ingestion has stopped
+	return c.JSON(http.StatusAccepted, ingest.IngestResponseDTO{
+		Id:       request.Id,
+		Filename: request.Filename,
+		State:    request.State,
+		Message:  "Cancellation requested..",
+	})
+}
+
+// GetQuarantinedVariantLines lists the malformed lines left out by a variant ingestion
+// request, or responds with the raw lines as a text file when 'format' is 'text'
+func GetQuarantinedVariantLines(c echo.Context) error {
+	fmt.Printf("[%s] - GetQuarantinedVariantLines hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	requestId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid ingestion request id %s - please provide a valid uuid", c.Param("id"))))
+	}
+	if _, exists := gc.IngestionService.GetVariantIngestionRequest(requestId); !exists {
+		return c.JSON(http.StatusNotFound, errors.CreateSimpleNotFound(fmt.Sprintf("variant ingestion request %s not found", requestId)))
+	}
+
+	lines, err := esRepo.GetQuarantinedLines(gc.Config, gc.Es7Client, requestId.String())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(err.Error()))
+	}
+
+	switch c.QueryParam("format") {
+	case "", "json":
+		return c.JSON(http.StatusOK, lines)
+	case "text":
+		var raw strings.Builder
+		for _, line := range lines {
+			raw.WriteString(line.Raw)
+			raw.WriteString("\n")
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s.quarantined.txt\"", requestId))
+		return c.String(http.StatusOK, raw.String())
+	default:
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid format %s - please provide 'json' or 'text'", c.QueryParam("format"))))
+	}
+}
+
 func GetDatasetVariantsCount(c echo.Context) int {
 	gc := c.(*contexts.GohanContext)
 	cfg := gc.Config
@@ -472,9 +899,10 @@ func GetDatasetVariantsCount(c echo.Context) int {
 	// request #1
 	g.Go(func() error {
 		docs, countError := esRepo.CountDocumentsContainerVariantOrSampleIdInPositionRange(cfg, es,
-			"*", 0, 0,
+			"*", 0, 0, rm.START,
 			"", "", dataset.String(), // note : both variantId and sampleId are deliberately set to ""
-			"", "", []string{}, "", "")
+			"", "", []string{}, "", "",
+			0, 0, 0, nil, nil) // note : no quality thresholds
 		if countError != nil {
 			fmt.Printf("Failed to count variants in dataset %s\n", dataset)
 			return countError
@@ -544,9 +972,10 @@ func GetDatasetSummary(c echo.Context) error {
 	// request #1
 	g.Go(func() error {
 		docs, countError := esRepo.CountDocumentsContainerVariantOrSampleIdInPositionRange(cfg, es,
-			"*", 0, 0,
+			"*", 0, 0, rm.START,
 			"", "", dataset.String(), // note : both variantId and sampleId are deliberately set to ""
-			"", "", []string{}, "", "")
+			"", "", []string{}, "", "",
+			0, 0, 0, nil, nil) // note : no quality thresholds
 		if countError != nil {
 			fmt.Printf("Failed to count variants in dataset %s\n", dataset)
 			return countError
@@ -607,30 +1036,21 @@ func ClearDataset(c echo.Context) error {
 	dataType := gc.DataType
 	fmt.Printf("[%s] - ClearDataset hit: [%s] - [%s]!\n", time.Now(), dataset.String(), dataType)
 
-	var (
-		deletionCount = 0.0
-		g             = new(errgroup.Group)
-	)
-	// request #1
-	g.Go(func() error {
-		deleteResponse, delErr := esRepo.DeleteVariantsByDatasetId(cfg, es, dataset.String())
-
-		if delErr != nil {
-			fmt.Printf("Failed to delete dataset %s variants\n", dataset)
-			return delErr
-		}
-
-		deletionCount = deleteResponse["deleted"].(float64)
-
-		return nil
-	})
+	// runs in the background, as clearing a large dataset outlasts any gateway timeout
+	taskId, err := esRepo.StartDeletingVariantsByDatasetId(cfg, es, dataset.String())
+	if err != nil {
+		fmt.Printf("Failed to delete dataset %s variants: %s\n", dataset, err)
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError("Something went wrong.. Please try again later!"))
+	}
 
-	if err := g.Wait(); err == nil {
-		fmt.Printf("Deleted %f variants from dataset %s\n", deletionCount, dataset)
-		return c.NoContent(http.StatusNoContent)
-	} else {
+	// its files are forgotten once their variants are all deleted, as with a single file
+	if err := esRepo.MarkDatasetVcfFilesDeleting(cfg, es, dataset.String(), taskId); err != nil {
+		fmt.Printf("Failed to mark dataset %s files as being deleted: %s\n", dataset, err)
 		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError("Something went wrong.. Please try again later!"))
 	}
+	go watchFileDeletion(cfg, es, taskId)
+
+	return respondWithDeletionTask(gc, taskId)
 }
 
 type DataTypeSummary struct {
@@ -745,11 +1165,13 @@ func executeGetByIds(c echo.Context, ids []string, isVariantIdQuery bool, isDocu
 				}
 
 				docs, searchErr = esRepo.GetDocumentsContainerVariantOrSampleIdInPositionRange(cfg, es,
-					chromosome, lowerBound, upperBound,
+					chromosome, lowerBound, upperBound, gc.RangeMode,
 					_id, "", datasetString, // note : "" is for sampleId
 					reference, alternative, alleles,
 					size, sortByPosition,
 					includeInfoInResultSet, genotype, assemblyId,
+					gc.MinGenotypeQuality, gc.MinReadDepth, gc.MinQual,
+					gc.MinInfo, gc.MaxInfo,
 					getSampleIdsOnly)
 			} else {
 
@@ -770,11 +1192,13 @@ func executeGetByIds(c echo.Context, ids []string, isVariantIdQuery bool, isDocu
 					}
 
 					docs, searchErr = esRepo.GetDocumentsContainerVariantOrSampleIdInPositionRange(cfg, es,
-						chromosome, lowerBound, upperBound,
+						chromosome, lowerBound, upperBound, gc.RangeMode,
 						"", _id, datasetString, // note : "" is for variantId
 						reference, alternative, alleles,
 						size, sortByPosition,
 						includeInfoInResultSet, genotype, assemblyId,
+						gc.MinGenotypeQuality, gc.MinReadDepth, gc.MinQual,
+						gc.MinInfo, gc.MaxInfo,
 						false)
 				}
 
@@ -847,13 +1271,21 @@ func executeGetByIds(c echo.Context, ids []string, isVariantIdQuery bool, isDocu
 					variant := source.(map[string]interface{})["variant"].(indexes.Variant)
 					docId := source.(map[string]interface{})["documentId"].(string)
 
-					alleles := variant.Sample.Variation.Alleles
+					variation := variant.Sample.Variation
+					// documents ingested prior to polyploid support only hold a left/right pair
+					alleles := variation.AllAlleles
+					if len(alleles) == 0 {
+						alleles = []string{variation.Alleles.Left, variation.Alleles.Right}
+					}
 
 					sampleId := strings.ToUpper(variant.Sample.Id)
 
 					variantResult.Calls = append(variantResult.Calls, dtos.VariantCall{
 						Chrom:  variant.Chrom,
 						Pos:    variant.Pos,
+						End:    variant.End,
+						SvType: variant.SvType,
+						SvLen:  variant.SvLen,
 						Id:     variant.Id,
 						Ref:    variant.Ref,
 						Alt:    variant.Alt,
@@ -864,11 +1296,23 @@ func executeGetByIds(c echo.Context, ids []string, isVariantIdQuery bool, isDocu
 						Info: variant.Info,
 
 						SampleId:     sampleId,
-						GenotypeType: zygosity.ZygosityToString(variant.Sample.Variation.Genotype.Zygosity),
-						Alleles:      []string{alleles.Left, alleles.Right},
-						Dataset:      variant.Dataset,
-						AssemblyId:   variant.AssemblyId,
-						DocumentId:   docId,
+						GenotypeType: zygosity.ZygosityToString(variation.Genotype.Zygosity),
+						Alleles:      alleles,
+						Phased:       variation.Genotype.PhasedSeparators,
+
+						GenotypeProbability:  variation.GenotypeProbability,
+						PhredScaleLikelyhood: variation.PhredScaleLikelyhood,
+						ReadDepth:            variation.ReadDepth,
+						GenotypeQuality:      variation.GenotypeQuality,
+						MinReadDepth:         variation.MinReadDepth,
+						AllelicDepths:        variation.AllelicDepths,
+						AlleleFractions:      variation.AlleleFractions,
+						StrandBias:           variation.StrandBias,
+						Formats:              variation.Formats,
+
+						Dataset:    variant.Dataset,
+						AssemblyId: variant.AssemblyId,
+						DocumentId: docId,
 					})
 				}
 			}
@@ -931,18 +1375,22 @@ func executeCountByIds(c echo.Context, ids []string, isVariantIdQuery bool) erro
 				countResult.Query = fmt.Sprintf("variantId:%s", _id) // TODO: Refactor
 
 				docs, countError = esRepo.CountDocumentsContainerVariantOrSampleIdInPositionRange(cfg, es,
-					chromosome, lowerBound, upperBound,
+					chromosome, lowerBound, upperBound, gc.RangeMode,
 					_id, "", datasetString, // note : "" is for sampleId
-					reference, alternative, alleles, genotype, assemblyId)
+					reference, alternative, alleles, genotype, assemblyId,
+					gc.MinGenotypeQuality, gc.MinReadDepth, gc.MinQual,
+					gc.MinInfo, gc.MaxInfo)
 			} else {
 				// implied sampleId query
 				fmt.Printf("Executing Count-Samples for SampleId %s\n", _id)
 				countResult.Query = fmt.Sprintf("sampleId:%s", _id) // TODO: Refactor
 
 				docs, countError = esRepo.CountDocumentsContainerVariantOrSampleIdInPositionRange(cfg, es,
-					chromosome, lowerBound, upperBound,
+					chromosome, lowerBound, upperBound, gc.RangeMode,
 					"", _id, datasetString, // note : "" is for variantId
-					reference, alternative, alleles, genotype, assemblyId)
+					reference, alternative, alleles, genotype, assemblyId,
+					gc.MinGenotypeQuality, gc.MinReadDepth, gc.MinQual,
+					gc.MinInfo, gc.MaxInfo)
 			}
 
 			if countError != nil {
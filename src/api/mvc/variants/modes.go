@@ -0,0 +1,107 @@
+package variants
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gohan/api/contexts"
+	esRepo "gohan/api/repositories/elasticsearch"
+	"gohan/api/services/vcf"
+
+	"github.com/google/uuid"
+)
+
+// findSampleCollisions lists the samples of a .vcf.gz already found in a dataset, as
+// the 'append' ingestion mode only allows for new samples to be added to a dataset
+func findSampleCollisions(gc *contexts.GohanContext, gzippedFilePath string, dataset uuid.UUID) ([]string, error) {
+	f, err := os.Open(gzippedFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return findSampleCollisionsInStream(gc, f, gzippedFilePath, dataset)
+}
+
+// findSampleCollisionsInStream is findSampleCollisions for a .vcf.gz read from elsewhere (i.e. DRS);
+// only its header is read
+func findSampleCollisionsInStream(gc *contexts.GohanContext, source io.Reader, gzippedFileName string, dataset uuid.UUID) ([]string, error) {
+	gr, err := gzip.NewReader(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid gzip file: %w", path.Base(gzippedFileName), err)
+	}
+	defer gr.Close()
+
+	header, err := vcf.ReadHeader(gr)
+	if err != nil {
+		return nil, err
+	}
+
+	resultingBuckets, err := esRepo.GetVariantsBucketsByKeywordAndDataset(gc.Config, gc.Es7Client, "sample.id.keyword", dataset.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// sample ids are indexed in lower case
+	existing := map[string]struct{}{}
+	if aggs, aggsOk := resultingBuckets["aggregations"].(map[string]interface{}); aggsOk {
+		items, _ := aggs["items"].(map[string]interface{})
+		buckets, _ := items["buckets"].([]interface{})
+		for _, bucket := range buckets {
+			if key, keyOk := bucket.(map[string]interface{})["key"].(string); keyOk {
+				existing[strings.ToLower(key)] = struct{}{}
+			}
+		}
+	}
+
+	collisions := []string{}
+	for _, sampleId := range header.SampleIds {
+		if _, exists := existing[strings.ToLower(sampleId)]; exists {
+			collisions = append(collisions, sampleId)
+		}
+	}
+	return collisions, nil
+}
+
+// replacePriorVariants removes what was ingested into a dataset from prior versions of a
+// .vcf.gz (same filename, or same fileId) now that its new version has been ingested.
+// Returns the number of documents deleted
+func replacePriorVariants(gc *contexts.GohanContext, gzippedFileName string, fileId string, dataset uuid.UUID, ingestionStart time.Time) (int64, error) {
+	cfg := gc.Config
+	es := gc.Es7Client
+
+	files, err := esRepo.GetVcfFilesByDataset(cfg, es, dataset.String())
+	if err != nil {
+		return 0, err
+	}
+
+	priorFileIds := []string{fileId} // the very same file may have been ingested before
+	for _, file := range files {
+		if file.DeletionTaskId != "" {
+			// on its way out already
+			continue
+		}
+		if file.Filename == path.Base(gzippedFileName) && file.FileId != fileId {
+			priorFileIds = append(priorFileIds, file.FileId)
+		}
+	}
+
+	deleteResponse, err := esRepo.DeleteVariantsOfReplacedFiles(cfg, es, dataset.String(), priorFileIds, ingestionStart)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, priorFileId := range priorFileIds[1:] {
+		if err := esRepo.DeleteVcfFile(cfg, es, dataset.String(), priorFileId); err != nil {
+			fmt.Printf("Failed to forget replaced file %s: %s\n", priorFileId, err)
+		}
+	}
+
+	deleted, _ := deleteResponse["deleted"].(float64)
+	return int64(deleted), nil
+}
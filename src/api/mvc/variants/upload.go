@@ -0,0 +1,282 @@
+package variants
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gohan/api/contexts"
+	"gohan/api/models/dtos/errors"
+	"gohan/api/models/ingest"
+	"gohan/api/services"
+	"gohan/api/utils"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+)
+
+var contentRangeRegexp = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+)$`)
+
+// VariantsIngestUpload receives one or more .vcf.gz files as multipart/form-data, each
+// as a 'file' part, along with one 'checksum' part per file (matched in order of appearance).
+// Verified files are then ingested the same way as those found in the VCF path
+func VariantsIngestUpload(c echo.Context) error {
+	fmt.Printf("[%s] - VariantsIngestUpload hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+	vcfPath := gc.Config.Api.VcfPath
+
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("expected a multipart/form-data request"))
+	}
+
+	type uploadedFile struct {
+		fileName string // relative to the vcf path
+		digests  map[string]string
+	}
+
+	var (
+		uploadId  = uuid.New()
+		uploaded  []uploadedFile
+		checksums []string
+	)
+	removeUploads := func() {
+		os.RemoveAll(path.Join(vcfPath, services.UploadsDirectory, uploadId.String()))
+	}
+
+	for {
+		part, partErr := reader.NextPart()
+		if partErr == io.EOF {
+			break
+		}
+		if partErr != nil {
+			removeUploads()
+			return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("malformed multipart request: %s", partErr)))
+		}
+
+		switch part.FormName() {
+		case "checksum":
+			value, _ := io.ReadAll(io.LimitReader(part, 1024))
+			checksums = append(checksums, strings.TrimSpace(string(value)))
+
+		case "file":
+			fileName := services.UploadFileName(uploadId, part.FileName())
+			if !strings.HasSuffix(fileName, ".vcf.gz") {
+				removeUploads()
+				return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("%s is not a .vcf.gz file", part.FileName())))
+			}
+
+			// stream to disk while hashing, as the checksum algorithm may not be known yet
+			digests, writeErr := writeUploadPart(path.Join(vcfPath, fileName), part)
+			if writeErr != nil {
+				removeUploads()
+				return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(fmt.Sprintf("failed to store %s: %s", part.FileName(), writeErr)))
+			}
+			uploaded = append(uploaded, uploadedFile{fileName: fileName, digests: digests})
+		}
+		part.Close()
+	}
+
+	if len(uploaded) == 0 {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing 'file' part"))
+	}
+	if len(checksums) != len(uploaded) {
+		removeUploads()
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("got %d files but %d checksums - please provide one checksum per file", len(uploaded), len(checksums))))
+	}
+
+	params := getUploadIngestionParameters(c)
+
+	responseDtos := []ingest.IngestResponseDTO{}
+	for idx, file := range uploaded {
+		algorithm, expected, checksumErr := utils.ParseChecksum(checksums[idx])
+		if checksumErr == nil && file.digests[algorithm] != expected {
+			checksumErr = fmt.Errorf("%s checksum mismatch: expected %s, got %s", algorithm, expected, file.digests[algorithm])
+		}
+		if checksumErr != nil {
+			os.Remove(path.Join(vcfPath, file.fileName))
+			responseDtos = append(responseDtos, ingest.IngestResponseDTO{
+				Filename: file.fileName,
+				State:    ingest.Error,
+				Message:  checksumErr.Error(),
+			})
+			continue
+		}
+
+		responseDtos = append(responseDtos, queueVariantIngestion(gc, file.fileName, params))
+	}
+
+	return c.JSON(http.StatusOK, responseDtos)
+}
+
+// CreateVariantUploadSession begins a resumable upload of a single .vcf.gz, whose
+// chunks are then sent with AppendToVariantUploadSession
+func CreateVariantUploadSession(c echo.Context) error {
+	fmt.Printf("[%s] - CreateVariantUploadSession hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	fileName := path.Base(c.QueryParam("fileName"))
+	if !strings.HasSuffix(fileName, ".vcf.gz") {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing or invalid 'fileName' - please provide the name of a .vcf.gz file"))
+	}
+
+	size, sizeErr := strconv.ParseInt(c.QueryParam("size"), 10, 64)
+	if sizeErr != nil || size <= 0 {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing or invalid 'size' - please provide the file size in bytes"))
+	}
+
+	checksum := c.QueryParam("checksum")
+	if _, _, checksumErr := utils.ParseChecksum(checksum); checksumErr != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("missing or invalid 'checksum': %s", checksumErr)))
+	}
+
+	params := getUploadIngestionParameters(c)
+	now := time.Now().String()
+	session := &ingest.VariantUploadSession{
+		Id:        uuid.New(),
+		Filename:  fileName,
+		Size:      size,
+		Checksum:  checksum,
+		CreatedAt: now,
+		UpdatedAt: now,
+
+		AssemblyId: params.assemblyId,
+		Dataset:    params.dataset,
+		Project:    params.projectId,
+		Options:    params.options,
+	}
+
+	if err := gc.IngestionService.CreateUploadSession(session); err != nil {
+		return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(fmt.Sprintf("failed to create upload: %s", err)))
+	}
+
+	return c.JSON(http.StatusCreated, session)
+}
+
+// GetVariantUploadSession reports how much of a resumable upload has been received
+func GetVariantUploadSession(c echo.Context) error {
+	fmt.Printf("[%s] - GetVariantUploadSession hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+
+	uploadId, err := uuid.Parse(c.Param("uploadId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid upload id %s - please provide a valid uuid", c.Param("uploadId"))))
+	}
+
+	session, exists := gc.IngestionService.GetUploadSession(uploadId)
+	if !exists {
+		return c.JSON(http.StatusNotFound, errors.CreateSimpleNotFound(fmt.Sprintf("upload %s not found", uploadId)))
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// AppendToVariantUploadSession receives a chunk of a resumable upload, described by a
+// 'Content-Range: bytes <start>-<end>/<size>' header. Once the last chunk is received, the
+// checksum is verified and the file is ingested; the usual ingestion response is returned
+func AppendToVariantUploadSession(c echo.Context) error {
+	fmt.Printf("[%s] - AppendToVariantUploadSession hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+	ingestionService := gc.IngestionService
+
+	uploadId, err := uuid.Parse(c.Param("uploadId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid upload id %s - please provide a valid uuid", c.Param("uploadId"))))
+	}
+
+	session, exists := ingestionService.GetUploadSession(uploadId)
+	if !exists {
+		return c.JSON(http.StatusNotFound, errors.CreateSimpleNotFound(fmt.Sprintf("upload %s not found", uploadId)))
+	}
+
+	matches := contentRangeRegexp.FindStringSubmatch(c.Request().Header.Get("Content-Range"))
+	if matches == nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing or invalid 'Content-Range' header - expected 'bytes <start>-<end>/<size>'"))
+	}
+	start, _ := strconv.ParseInt(matches[1], 10, 64)
+	end, _ := strconv.ParseInt(matches[2], 10, 64)
+	total, _ := strconv.ParseInt(matches[3], 10, 64)
+	if total != session.Size || end < start || end >= total {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("invalid 'Content-Range' for an upload of %d bytes", session.Size)))
+	}
+
+	session, err = ingestionService.AppendToUploadSession(uploadId, start, io.LimitReader(c.Request().Body, end-start+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(err.Error()))
+	}
+
+	if session.Offset < session.Size {
+		// more chunks to come
+		return c.JSON(http.StatusOK, session)
+	}
+
+	// -- upload complete
+	fileName := services.UploadFileName(session.Id, session.Filename)
+	if err := utils.VerifyFileChecksum(path.Join(gc.Config.Api.VcfPath, fileName), session.Checksum); err != nil {
+		ingestionService.RemoveUploadSession(uploadId, true)
+		return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(err.Error()))
+	}
+	ingestionService.RemoveUploadSession(uploadId, false)
+
+	return c.JSON(http.StatusOK, []ingest.IngestResponseDTO{
+		queueVariantIngestion(gc, fileName, variantIngestionParameters{
+			assemblyId:           session.AssemblyId,
+			dataset:              session.Dataset,
+			projectId:            session.Project,
+			datasetId:            session.Dataset.String(),
+			authHeader:           c.Request().Header.Get("Authorization"),
+			options:              session.Options,
+			removeSourceWhenDone: true,
+		}),
+	})
+}
+
+// -- internal use only --
+func getUploadIngestionParameters(c echo.Context) variantIngestionParameters {
+	gc := c.(*contexts.GohanContext)
+
+	return variantIngestionParameters{
+		assemblyId:           gc.AssemblyId,
+		dataset:              gc.Dataset,
+		projectId:            c.QueryParam("project"),
+		datasetId:            gc.Dataset.String(),
+		authHeader:           c.Request().Header.Get("Authorization"),
+		options:              getVariantIngestOptionsQueryParams(c),
+		removeSourceWhenDone: true,
+	}
+}
+
+func writeUploadPart(destination string, part io.Reader) (map[string]string, error) {
+	if err := os.MkdirAll(path.Dir(destination), 0700); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(destination)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := map[string]hash.Hash{}
+	writers := []io.Writer{f}
+	for _, algorithm := range utils.SupportedChecksumAlgorithms {
+		hashes[algorithm] = utils.NewChecksumHash(algorithm)
+		writers = append(writers, hashes[algorithm])
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), part); err != nil {
+		return nil, err
+	}
+
+	digests := map[string]string{}
+	for algorithm, h := range hashes {
+		digests[algorithm] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return digests, nil
+}
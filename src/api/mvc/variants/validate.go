@@ -0,0 +1,124 @@
+package variants
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gohan/api/contexts"
+	"gohan/api/models/dtos/errors"
+	"gohan/api/models/ingest"
+	"gohan/api/services"
+	"gohan/api/services/vcf"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+)
+
+// ValidateVariantFile reads a .vcf.gz the same way an ingestion would, without indexing
+// anything, and responds with the problems found. The file is either uploaded as the
+// 'file' part of a multipart/form-data request, or found in the VCF path ('fileName').
+// The contigs are checked against the reference genome of 'assemblyId', when provided
+func ValidateVariantFile(c echo.Context) error {
+	fmt.Printf("[%s] - ValidateVariantFile hit!\n", time.Now())
+	gc := c.(*contexts.GohanContext)
+	vcfPath := gc.Config.Api.VcfPath
+
+	options := getVariantIngestOptionsQueryParams(c)
+	options.DryRun = true
+
+	var fileName string // relative to the vcf path
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		reader, err := c.Request().MultipartReader()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("malformed multipart request: %s", err)))
+		}
+
+		uploadId := uuid.New()
+		defer os.RemoveAll(path.Join(vcfPath, services.UploadsDirectory, uploadId.String()))
+
+		for fileName == "" {
+			part, partErr := reader.NextPart()
+			if partErr == io.EOF {
+				break
+			}
+			if partErr != nil {
+				return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest(fmt.Sprintf("malformed multipart request: %s", partErr)))
+			}
+			if part.FormName() != "file" {
+				part.Close()
+				continue
+			}
+
+			fileName = services.UploadFileName(uploadId, part.FileName())
+			if _, writeErr := writeUploadPart(path.Join(vcfPath, fileName), part); writeErr != nil {
+				return c.JSON(http.StatusInternalServerError, errors.CreateSimpleInternalServerError(fmt.Sprintf("failed to store %s: %s", part.FileName(), writeErr)))
+			}
+			part.Close()
+		}
+		if fileName == "" {
+			return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing 'file' part"))
+		}
+	} else {
+		if c.QueryParam("fileName") == "" {
+			return c.JSON(http.StatusBadRequest, errors.CreateSimpleBadRequest("missing 'fileName' query parameter, or 'file' part of a multipart/form-data request"))
+		}
+
+		resolved, err := resolveVariantFileNames(gc.Config, []string{c.QueryParam("fileName")}, "")
+		if err != nil {
+			return c.JSON(http.StatusNotFound, errors.CreateSimpleNotFound(err.Error()))
+		}
+		fileName = resolved[0]
+	}
+
+	report := validateVcf(c.Request().Context(), gc, path.Join(vcfPath, fileName), c.QueryParam("assemblyId"), options)
+	report.Filename = path.Base(fileName)
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// validateVcf runs a .vcf.gz through the ingestion parser without indexing it
+func validateVcf(ctx context.Context, gc *contexts.GohanContext, filePath string, assemblyId string, options ingest.VariantIngestOptions) ingest.VcfValidationReport {
+	options.DryRun = true
+
+	validator := vcf.NewValidator(path.Base(filePath))
+	_, err := gc.IngestionService.ProcessVcf(ctx, uuid.Nil, filePath, "", uuid.Nil, assemblyId, options,
+		gc.Config.Api.LineProcessingConcurrencyLevel, &ingest.VariantIngestProgress{}, validator)
+	if err != nil {
+		validator.LineError(0, err.Error())
+	}
+
+	return validator.Report()
+}
+
+// validateVcfStream is validateVcf for a .vcf.gz read from elsewhere (i.e. DRS)
+func validateVcfStream(ctx context.Context, gc *contexts.GohanContext, source io.Reader, totalBytes int64, fileName string, assemblyId string, options ingest.VariantIngestOptions, progress *ingest.VariantIngestProgress) ingest.VcfValidationReport {
+	options.DryRun = true
+
+	validator := vcf.NewValidator(path.Base(fileName))
+	_, err := gc.IngestionService.ProcessVcfStream(ctx, uuid.Nil, source, totalBytes, fileName, "", uuid.Nil, assemblyId, options,
+		gc.Config.Api.LineProcessingConcurrencyLevel, progress, validator)
+	if err != nil {
+		validator.LineError(0, err.Error())
+	}
+
+	return validator.Report()
+}
+
+// completeDryRun reports on an ingestion request that only validated its file
+func completeDryRun(ctx context.Context, ingestionService *services.IngestionService, reqStat *ingest.VariantIngestRequest, report ingest.VcfValidationReport) {
+	reqStat.Validation = &report
+
+	reqStat.State = ingest.Done
+	reqStat.Message = fmt.Sprintf("Dry run: %d records read, %d errors found", report.RecordCount, report.ErrorCount)
+	if ctx.Err() != nil {
+		reqStat.State = ingest.Cancelled
+		reqStat.Message = "Cancelled during the dry run"
+	}
+	ingestionService.IngestRequestChan <- reqStat
+}
@@ -0,0 +1,55 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"strings"
+
+	"gohan/api/models"
+	"gohan/api/models/indexes"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+// GetCoverageAtPosition returns the gVCF reference blocks of a sample spanning a position
+func GetCoverageAtPosition(cfg *models.Config, es *elasticsearch.Client,
+	chromosome string, position int, sampleId string, datasetString string, assemblyId string) ([]*indexes.Coverage, error) {
+
+	mustMap := []map[string]interface{}{
+		{
+			"range": map[string]interface{}{
+				"start": map[string]interface{}{"lte": position},
+			},
+		},
+		{
+			"range": map[string]interface{}{
+				"end": map[string]interface{}{"gte": position},
+			},
+		},
+		// sample ids are indexed lowercased
+		{"term": map[string]interface{}{"sampleId.keyword": strings.ToLower(sampleId)}},
+		{
+			"match": map[string]interface{}{
+				"assemblyId": map[string]interface{}{
+					"query": assemblyId,
+				},
+			},
+		},
+	}
+
+	if datasetString != "" {
+		mustMap = append(mustMap, map[string]interface{}{
+			"query_string": map[string]interface{}{
+				"fields": []string{"dataset.keyword"},
+				"query":  datasetString,
+			},
+		})
+	}
+
+	return searchDocuments[indexes.Coverage](cfg, es,
+		fmt.Sprintf("coverage-%s", strings.ToLower(chromosome)),
+		map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": mustMap,
+			},
+		})
+}
@@ -0,0 +1,154 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gohan/api/models"
+	"gohan/api/models/indexes"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+const vcfFilesIndex = "vcf-files"
+
+// SaveVcfFile records an ingested .vcf.gz, identified by its dataset and fileId
+// (identical files share their fileId across the datasets they're ingested into)
+func SaveVcfFile(cfg *models.Config, es *elasticsearch.Client, file *indexes.VcfFile) error {
+	if err := makeIndexIfNotExists(cfg, es, vcfFilesIndex, indexes.VCF_FILE_INDEX_MAPPING); err != nil {
+		return err
+	}
+	return saveDocument(cfg, es, vcfFilesIndex, vcfFileDocumentId(file.Dataset, file.FileId), file)
+}
+
+// DeleteVcfFile forgets a .vcf.gz ingested into a dataset, i.e. once replaced by a new version.
+// Records are looked up by content, such that those keyed on their fileId alone
+// (as they were before being keyed on their dataset as well) are found too
+func DeleteVcfFile(cfg *models.Config, es *elasticsearch.Client, dataset string, fileId string) error {
+	return deleteVcfFiles(cfg, es, vcfFileQuery(dataset, fileId))
+}
+
+// DeleteVcfFilesByDeletionTask forgets the files whose variants the given task deleted.
+// Files ingested again since are kept, as recording them again unmarked them
+func DeleteVcfFilesByDeletionTask(cfg *models.Config, es *elasticsearch.Client, taskId string) error {
+	return deleteVcfFiles(cfg, es, deletionTaskQuery(taskId))
+}
+
+// MarkVcfFileDeleting records the task deleting the variants a dataset got from a .vcf.gz
+func MarkVcfFileDeleting(cfg *models.Config, es *elasticsearch.Client, dataset string, fileId string, taskId string) error {
+	return updateVcfFiles(cfg, es, vcfFileQuery(dataset, fileId), map[string]interface{}{
+		"source": "ctx._source.deletionTaskId = params.taskId",
+		"params": map[string]interface{}{"taskId": taskId},
+	})
+}
+
+// MarkDatasetVcfFilesDeleting records the task deleting all the variants of a dataset
+func MarkDatasetVcfFilesDeleting(cfg *models.Config, es *elasticsearch.Client, dataset string, taskId string) error {
+	return updateVcfFiles(cfg, es, map[string]interface{}{
+		"term": map[string]interface{}{"dataset.keyword": dataset},
+	}, map[string]interface{}{
+		"source": "ctx._source.deletionTaskId = params.taskId",
+		"params": map[string]interface{}{"taskId": taskId},
+	})
+}
+
+// UnmarkVcfFilesDeleting keeps the files a deletion task failed to delete the variants of
+func UnmarkVcfFilesDeleting(cfg *models.Config, es *elasticsearch.Client, taskId string) error {
+	return updateVcfFiles(cfg, es, deletionTaskQuery(taskId), map[string]interface{}{
+		"source": "ctx._source.remove('deletionTaskId')",
+	})
+}
+
+func GetVcfFilesByDataset(cfg *models.Config, es *elasticsearch.Client, dataset string) ([]*indexes.VcfFile, error) {
+	return searchDocuments[indexes.VcfFile](cfg, es, vcfFilesIndex, map[string]interface{}{
+		"term": map[string]interface{}{
+			"dataset.keyword": dataset,
+		},
+	})
+}
+
+// -- internal use only --
+func deleteVcfFiles(cfg *models.Config, es *elasticsearch.Client, query map[string]interface{}) error {
+	if cfg.Debug {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"query": query}); err != nil {
+		return err
+	}
+
+	res, err := es.DeleteByQuery([]string{vcfFilesIndex}, &buf,
+		es.DeleteByQuery.WithConflicts("proceed"),
+		es.DeleteByQuery.WithRefresh(true),
+	)
+	if err != nil {
+		fmt.Printf("Error getting response: %s\n", err)
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete files from %s : got '%s'", vcfFilesIndex, res.Status())
+	}
+	return nil
+}
+
+func updateVcfFiles(cfg *models.Config, es *elasticsearch.Client, query map[string]interface{}, script map[string]interface{}) error {
+	if cfg.Debug {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"query":  query,
+		"script": script,
+	}); err != nil {
+		return err
+	}
+
+	res, err := es.UpdateByQuery([]string{vcfFilesIndex},
+		es.UpdateByQuery.WithBody(&buf),
+		es.UpdateByQuery.WithConflicts("proceed"),
+		es.UpdateByQuery.WithRefresh(true),
+	)
+	if err != nil {
+		fmt.Printf("Error getting response: %s\n", err)
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to update files of %s : got '%s'", vcfFilesIndex, res.Status())
+	}
+	return nil
+}
+
+func vcfFileQuery(dataset string, fileId string) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []map[string]interface{}{
+				{"term": map[string]interface{}{"dataset.keyword": dataset}},
+				{"term": map[string]interface{}{"fileId.keyword": fileId}},
+			},
+		},
+	}
+}
+
+func deletionTaskQuery(taskId string) map[string]interface{} {
+	return map[string]interface{}{
+		"term": map[string]interface{}{
+			"deletionTaskId.keyword": taskId,
+		},
+	}
+}
+
+// vcfFileDocumentId keeps fileIds holding URIs (i.e. 'drs://host/id') usable as document ids,
+// which the client does not escape when building request paths
+func vcfFileDocumentId(dataset string, fileId string) string {
+	return url.PathEscape(fmt.Sprintf("%s/%s", dataset, fileId))
+}
@@ -0,0 +1,276 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gohan/api/models"
+	"gohan/api/models/indexes"
+	"gohan/api/models/ingest"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+const (
+	// documents are searched through a page at a time, each page
+	// being kept around for at most that long before the next is requested
+	searchPageSize        = 1000
+	searchScrollKeepAlive = time.Minute
+
+	variantIngestionRequestsIndex = "ingestion-requests-variants"
+	geneIngestionRequestsIndex    = "ingestion-requests-genes"
+	variantIngestionJobsIndex     = "ingestion-jobs-variants"
+
+	// lines of VCFs that couldn't be ingested
+	VariantQuarantineIndex = "ingestion-quarantine-variants"
+)
+
+// MakeIngestionRequestIndices creates the indices backing the durable
+// ingestion request store if they do not exist yet
+func MakeIngestionRequestIndices(cfg *models.Config, es *elasticsearch.Client) error {
+	for _, index := range []string{variantIngestionRequestsIndex, geneIngestionRequestsIndex} {
+		if err := makeIndexIfNotExists(cfg, es, index, indexes.INGESTION_REQUEST_INDEX_MAPPING); err != nil {
+			return err
+		}
+	}
+	if err := makeIndexIfNotExists(cfg, es, variantIngestionJobsIndex, indexes.INGESTION_JOB_INDEX_MAPPING); err != nil {
+		return err
+	}
+	return makeIndexIfNotExists(cfg, es, VariantQuarantineIndex, indexes.QUARANTINE_INDEX_MAPPING)
+}
+
+func SaveVariantIngestionRequest(cfg *models.Config, es *elasticsearch.Client, request *ingest.VariantIngestRequest) error {
+	return saveDocument(cfg, es, variantIngestionRequestsIndex, request.Id.String(), request)
+}
+
+func GetAllVariantIngestionRequests(cfg *models.Config, es *elasticsearch.Client) ([]*ingest.VariantIngestRequest, error) {
+	return getAllDocuments[ingest.VariantIngestRequest](cfg, es, variantIngestionRequestsIndex)
+}
+
+func SaveGeneIngestionRequest(cfg *models.Config, es *elasticsearch.Client, request *ingest.GeneIngestRequest) error {
+	// gene ingestion requests are uniquely identified by their filename
+	return saveDocument(cfg, es, geneIngestionRequestsIndex, request.Filename, request)
+}
+
+func GetAllGeneIngestionRequests(cfg *models.Config, es *elasticsearch.Client) ([]*ingest.GeneIngestRequest, error) {
+	return getAllDocuments[ingest.GeneIngestRequest](cfg, es, geneIngestionRequestsIndex)
+}
+
+func SaveVariantIngestJob(cfg *models.Config, es *elasticsearch.Client, job *ingest.VariantIngestJob) error {
+	// jobs are looked up by the idempotency key they were submitted with
+	return saveDocument(cfg, es, variantIngestionJobsIndex, job.IdempotencyKey, job)
+}
+
+// GetVariantIngestJob returns the job submitted with the given
+// idempotency key, or nil if there is no such job
+func GetVariantIngestJob(cfg *models.Config, es *elasticsearch.Client, idempotencyKey string) (*ingest.VariantIngestJob, error) {
+	return getDocument[ingest.VariantIngestJob](cfg, es, variantIngestionJobsIndex, idempotencyKey)
+}
+
+// GetQuarantinedLines returns the lines quarantined by a variant
+// ingestion request, in order of appearance
+func GetQuarantinedLines(cfg *models.Config, es *elasticsearch.Client, requestId string) ([]*indexes.QuarantinedLine, error) {
+	lines, err := searchDocuments[indexes.QuarantinedLine](cfg, es, VariantQuarantineIndex, map[string]interface{}{
+		"term": map[string]interface{}{
+			"requestId": requestId,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(lines, func(a, b int) bool { return lines[a].Line < lines[b].Line })
+	return lines, nil
+}
+
+// -- internal use only --
+func makeIndexIfNotExists(cfg *models.Config, es *elasticsearch.Client, index string, mapping map[string]interface{}) error {
+	if cfg.Debug {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	res, err := es.Indices.Exists([]string{index})
+	if err != nil {
+		fmt.Printf("Index %s existence-check got error: %s\n", index, err)
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 404 {
+		// the index already exists, so we shouldn't try to recreate it.
+		return nil
+	}
+
+	mappings, _ := json.Marshal(mapping)
+	createRes, createErr := es.Indices.Create(
+		index,
+		es.Indices.Create.WithBody(strings.NewReader(fmt.Sprintf(`{"mappings": %s}`, mappings))),
+	)
+	if createErr != nil {
+		fmt.Printf("Error creating index %s: %s\n", index, createErr)
+		return createErr
+	}
+	defer createRes.Body.Close()
+
+	fmt.Printf("Creating index %s - got response: %s\n", index, createRes.String())
+	if createRes.IsError() && !strings.Contains(createRes.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("failed to create index %s : got '%s'", index, createRes.Status())
+	}
+	return nil
+}
+
+func saveDocument(cfg *models.Config, es *elasticsearch.Client, index string, documentId string, document interface{}) error {
+	data, err := json.Marshal(document)
+	if err != nil {
+		fmt.Printf("Error encoding document %s: %s\n", documentId, err)
+		return err
+	}
+
+	if cfg.Debug {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	res, indexErr := es.Index(
+		index,
+		bytes.NewReader(data),
+		es.Index.WithContext(context.Background()),
+		es.Index.WithDocumentID(documentId),
+		es.Index.WithRefresh("true"),
+	)
+	if indexErr != nil {
+		fmt.Printf("Error getting response: %s\n", indexErr)
+		return indexErr
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to save document %s to %s : got '%s'", documentId, index, res.Status())
+	}
+	return nil
+}
+
+func getDocument[T any](cfg *models.Config, es *elasticsearch.Client, index string, documentId string) (*T, error) {
+	if cfg.Debug {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	res, getErr := es.Get(
+		index,
+		documentId,
+		es.Get.WithContext(context.Background()),
+	)
+	if getErr != nil {
+		fmt.Printf("Error getting response: %s\n", getErr)
+		return nil, getErr
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		// either the document or the index doesn't exist
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to get document %s from %s : got '%s'", documentId, index, res.Status())
+	}
+
+	var result struct {
+		Source T `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		fmt.Printf("Error unmarshalling response: %s\n", err)
+		return nil, err
+	}
+	return &result.Source, nil
+}
+
+func getAllDocuments[T any](cfg *models.Config, es *elasticsearch.Client, index string) ([]*T, error) {
+	return searchDocuments[T](cfg, es, index, map[string]interface{}{
+		"match_all": map[string]interface{}{},
+	})
+}
+
+// searchDocuments returns every document matching the filter, scrolling
+// through them a page at a time rather than stopping at the first 10000
+func searchDocuments[T any](cfg *models.Config, es *elasticsearch.Client, index string, filter map[string]interface{}) ([]*T, error) {
+	var buf bytes.Buffer
+	query := map[string]interface{}{
+		"size":  searchPageSize,
+		"sort":  []string{"_doc"}, // cheapest order to scroll in
+		"query": filter,
+	}
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		fmt.Printf("Error encoding query: %s\n", err)
+		return nil, err
+	}
+
+	if cfg.Debug {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	res, searchErr := es.Search(
+		es.Search.WithContext(context.Background()),
+		es.Search.WithIndex(index),
+		es.Search.WithBody(&buf),
+		es.Search.WithIgnoreUnavailable(true),
+		es.Search.WithScroll(searchScrollKeepAlive),
+	)
+
+	documents := []*T{}
+	scrollId := ""
+	defer func() {
+		if scrollId != "" {
+			if res, err := es.ClearScroll(es.ClearScroll.WithScrollID(scrollId)); err == nil {
+				res.Body.Close()
+			}
+		}
+	}()
+
+	for {
+		if searchErr != nil {
+			fmt.Printf("Error getting response: %s\n", searchErr)
+			return nil, searchErr
+		}
+
+		var result struct {
+			ScrollId string `json:"_scroll_id"`
+			Hits     struct {
+				Hits []struct {
+					Source T `json:"_source"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if res.IsError() {
+			res.Body.Close()
+			return nil, fmt.Errorf("failed to get documents from %s : got '%s'", index, res.Status())
+		}
+		err := json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			fmt.Printf("Error unmarshalling response: %s\n", err)
+			return nil, err
+		}
+		if result.ScrollId != "" {
+			scrollId = result.ScrollId
+		}
+
+		for idx := range result.Hits.Hits {
+			documents = append(documents, &result.Hits.Hits[idx].Source)
+		}
+		if len(result.Hits.Hits) < searchPageSize || scrollId == "" {
+			return documents, nil
+		}
+
+		res, searchErr = es.Scroll(
+			es.Scroll.WithContext(context.Background()),
+			es.Scroll.WithScrollID(scrollId),
+			es.Scroll.WithScroll(searchScrollKeepAlive),
+		)
+	}
+}
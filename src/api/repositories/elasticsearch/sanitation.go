@@ -0,0 +1,166 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"gohan/api/models"
+	"gohan/api/models/indexes"
+	"gohan/api/models/sanitation"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+const sanitationRunsIndex = "sanitation-runs"
+
+// VariantDocument is a variant as stored, along with its document id
+type VariantDocument struct {
+	Id      string
+	Index   string
+	Variant indexes.Variant
+}
+
+func SaveSanitationRun(cfg *models.Config, es *elasticsearch.Client, run *sanitation.SanitationRun) error {
+	if err := makeIndexIfNotExists(cfg, es, sanitationRunsIndex, indexes.SANITATION_RUN_INDEX_MAPPING); err != nil {
+		return err
+	}
+	return saveDocument(cfg, es, sanitationRunsIndex, run.Id.String(), run)
+}
+
+// GetAllSanitationRuns returns the reports of the sanitation runs, most recent first
+func GetAllSanitationRuns(cfg *models.Config, es *elasticsearch.Client) ([]*sanitation.SanitationRun, error) {
+	runs, err := getAllDocuments[sanitation.SanitationRun](cfg, es, sanitationRunsIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	return runs, nil
+}
+
+// GetColocatedVariantDocuments pages through the variants of each dataset grouped by
+// assembly, sample and position (up to 100 per group, most recent first), leaving out
+// groups of a single variant. Returns the key to resume after, or nil once done
+func GetColocatedVariantDocuments(cfg *models.Config, es *elasticsearch.Client, after map[string]interface{}) ([][]VariantDocument, map[string]interface{}, error) {
+	composite := map[string]interface{}{
+		"size": 1000,
+		"sources": []map[string]interface{}{
+			{"dataset": map[string]interface{}{"terms": map[string]interface{}{"field": "dataset.keyword"}}},
+			{"assemblyId": map[string]interface{}{"terms": map[string]interface{}{"field": "assemblyId.keyword"}}},
+			{"chrom": map[string]interface{}{"terms": map[string]interface{}{"field": "chrom.keyword"}}},
+			{"pos": map[string]interface{}{"terms": map[string]interface{}{"field": "pos"}}},
+			{"sample": map[string]interface{}{"terms": map[string]interface{}{"field": "sample.id.keyword"}}},
+		},
+	}
+	if after != nil {
+		composite["after"] = after
+	}
+
+	var buf bytes.Buffer
+	query := map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"positions": map[string]interface{}{
+				"composite": composite,
+				"aggs": map[string]interface{}{
+					"documents": map[string]interface{}{
+						"top_hits": map[string]interface{}{
+							"size": 100,
+							"sort": []map[string]interface{}{
+								{"createdTime": map[string]interface{}{"order": "desc"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		fmt.Printf("Error encoding query: %s\n", err)
+		return nil, nil, err
+	}
+
+	if cfg.Debug {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	res, searchErr := es.Search(
+		es.Search.WithContext(context.Background()),
+		es.Search.WithIndex(wildcardVariantsIndex),
+		es.Search.WithBody(&buf),
+		es.Search.WithIgnoreUnavailable(true),
+	)
+	if searchErr != nil {
+		fmt.Printf("Error getting response: %s\n", searchErr)
+		return nil, nil, searchErr
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, nil, fmt.Errorf("failed to group variants by position : got '%s'", res.Status())
+	}
+
+	var result struct {
+		Aggregations struct {
+			Positions struct {
+				AfterKey map[string]interface{} `json:"after_key"`
+				Buckets  []struct {
+					DocCount  int64 `json:"doc_count"`
+					Documents struct {
+						Hits struct {
+							Hits []struct {
+								Index  string          `json:"_index"`
+								Id     string          `json:"_id"`
+								Source indexes.Variant `json:"_source"`
+							} `json:"hits"`
+						} `json:"hits"`
+					} `json:"documents"`
+				} `json:"buckets"`
+			} `json:"positions"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		fmt.Printf("Error unmarshalling response: %s\n", err)
+		return nil, nil, err
+	}
+
+	groups := [][]VariantDocument{}
+	for _, bucket := range result.Aggregations.Positions.Buckets {
+		if bucket.DocCount < 2 {
+			continue
+		}
+
+		group := make([]VariantDocument, 0, len(bucket.Documents.Hits.Hits))
+		for _, hit := range bucket.Documents.Hits.Hits {
+			group = append(group, VariantDocument{Id: hit.Id, Index: hit.Index, Variant: hit.Source})
+		}
+		groups = append(groups, group)
+	}
+
+	if len(result.Aggregations.Positions.Buckets) == 0 {
+		return groups, nil, nil
+	}
+	return groups, result.Aggregations.Positions.AfterKey, nil
+}
+
+// DeleteVariantsByDocumentIds removes the given variant documents, returning how many were deleted
+func DeleteVariantsByDocumentIds(cfg *models.Config, es *elasticsearch.Client, ids []string) (int64, error) {
+	result, err := deleteVariantsByQuery(cfg, es, map[string]interface{}{
+		"ids": map[string]interface{}{
+			"values": ids,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, _ := result["deleted"].(float64)
+	return int64(deleted), nil
+}
@@ -14,15 +14,21 @@ import (
 	"gohan/api/models"
 	c "gohan/api/models/constants"
 	gq "gohan/api/models/constants/genotype-query"
+	rm "gohan/api/models/constants/range-mode"
 	s "gohan/api/models/constants/sort"
 	z "gohan/api/models/constants/zygosity"
+	"gohan/api/models/dtos"
 	"gohan/api/utils"
 
 	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
 )
 
 const wildcardVariantsIndex = "variants-*"
 
+// gVCF reference blocks, deleted along with the variants of the same dataset or file
+const wildcardCoverageIndex = "coverage-*"
+
 func GetDocumentsByDocumentId(cfg *models.Config, es *elasticsearch.Client, id string) (map[string]interface{}, error) {
 
 	// overall query structure
@@ -104,12 +110,14 @@ func GetDocumentsByDocumentId(cfg *models.Config, es *elasticsearch.Client, id s
 }
 
 func GetDocumentsContainerVariantOrSampleIdInPositionRange(cfg *models.Config, es *elasticsearch.Client,
-	chromosome string, lowerBound int, upperBound int,
+	chromosome string, lowerBound int, upperBound int, rangeMode c.RangeMode,
 	variantId string, sampleId string, datasetString string,
 	reference string, alternative string, alleles []string,
 	size int, sortByPosition c.SortDirection,
 	includeInfoInResultSet bool,
 	genotype c.GenotypeQuery, assemblyId string,
+	minGenotypeQuality int, minReadDepth int, minQual float64,
+	minInfo map[string]float64, maxInfo map[string]float64,
 	getSampleIdsOnly bool) (map[string]interface{}, error) {
 
 	// begin building the request body.
@@ -178,33 +186,15 @@ func GetDocumentsContainerVariantOrSampleIdInPositionRange(cfg *models.Config, e
 		})
 	}
 
-	rangeMapSlice := []map[string]interface{}{}
-
-	// TODO: make upperbound and lowerbound nilable, somehow?
-	if upperBound > 0 {
-		rangeMapSlice = append(rangeMapSlice, map[string]interface{}{
-			"range": map[string]interface{}{
-				"pos": map[string]interface{}{
-					"lte": upperBound,
-				},
-			},
-		})
-	}
-
-	if lowerBound > 0 {
-		rangeMapSlice = append(rangeMapSlice, map[string]interface{}{
-			"range": map[string]interface{}{
-				"pos": map[string]interface{}{
-					"gte": lowerBound,
-				},
-			},
-		})
-	}
+	rangeMapSlice := addPositionBoundsToRangeMapSlice(lowerBound, upperBound, rangeMode, []map[string]interface{}{})
 
 	if genotype != gq.UNCALLED {
 		mustMap = addZygosityToMustMap(genotype, mustMap)
 	}
 
+	rangeMapSlice = addQualityThresholdsToRangeMapSlice(minGenotypeQuality, minReadDepth, minQual, rangeMapSlice)
+	rangeMapSlice = addInfoBoundsToRangeMapSlice(minInfo, maxInfo, rangeMapSlice)
+
 	// individually append each range components to the must map
 	if len(rangeMapSlice) > 0 {
 		for _, rms := range rangeMapSlice {
@@ -401,10 +391,12 @@ func GetMostRecentVariantTimestamp(cfg *models.Config, es *elasticsearch.Client,
 }
 
 func CountDocumentsContainerVariantOrSampleIdInPositionRange(cfg *models.Config, es *elasticsearch.Client,
-	chromosome string, lowerBound int, upperBound int,
+	chromosome string, lowerBound int, upperBound int, rangeMode c.RangeMode,
 	variantId string, sampleId string, datasetString string,
 	reference string, alternative string, alleles []string,
-	genotype c.GenotypeQuery, assemblyId string) (map[string]interface{}, error) {
+	genotype c.GenotypeQuery, assemblyId string,
+	minGenotypeQuality int, minReadDepth int, minQual float64,
+	minInfo map[string]float64, maxInfo map[string]float64) (map[string]interface{}, error) {
 
 	// begin building the request body.
 	mustMap := []map[string]interface{}{{
@@ -474,28 +466,7 @@ func CountDocumentsContainerVariantOrSampleIdInPositionRange(cfg *models.Config,
 		})
 	}
 
-	rangeMapSlice := []map[string]interface{}{}
-
-	// TODO: make upperbound and lowerbound nilable, somehow?
-	if upperBound > 0 {
-		rangeMapSlice = append(rangeMapSlice, map[string]interface{}{
-			"range": map[string]interface{}{
-				"pos": map[string]interface{}{
-					"lte": upperBound,
-				},
-			},
-		})
-	}
-
-	if lowerBound > 0 {
-		rangeMapSlice = append(rangeMapSlice, map[string]interface{}{
-			"range": map[string]interface{}{
-				"pos": map[string]interface{}{
-					"gte": lowerBound,
-				},
-			},
-		})
-	}
+	rangeMapSlice := addPositionBoundsToRangeMapSlice(lowerBound, upperBound, rangeMode, []map[string]interface{}{})
 
 	if genotype != gq.UNCALLED {
 		mustMap = addZygosityToMustMap(genotype, mustMap)
@@ -508,6 +479,9 @@ func CountDocumentsContainerVariantOrSampleIdInPositionRange(cfg *models.Config,
 		})
 	}
 
+	rangeMapSlice = addQualityThresholdsToRangeMapSlice(minGenotypeQuality, minReadDepth, minQual, rangeMapSlice)
+	rangeMapSlice = addInfoBoundsToRangeMapSlice(minInfo, maxInfo, rangeMapSlice)
+
 	// individually append each range components to the must map
 	if len(rangeMapSlice) > 0 {
 		for _, rms := range rangeMapSlice {
@@ -751,18 +725,202 @@ func GetVariantsBucketsByKeywordAndDataset(cfg *models.Config, es *elasticsearch
 	return result, nil
 }
 
-func DeleteVariantsByDatasetId(cfg *models.Config, es *elasticsearch.Client, dataset string) (map[string]interface{}, error) {
-	var buf bytes.Buffer
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"match": map[string]interface{}{
-				"dataset": dataset,
+// StartDeletingVariantsByDatasetId starts deleting, in the background, all the variants
+// (and gVCF reference blocks) of a dataset. Returns the deletion's task id
+func StartDeletingVariantsByDatasetId(cfg *models.Config, es *elasticsearch.Client, dataset string) (string, error) {
+	return startDeletingVariantsByQuery(cfg, es, map[string]interface{}{
+		"match": map[string]interface{}{
+			"dataset": dataset,
+		},
+	})
+}
+
+// DeleteVariantsByFileId removes the variants (and gVCF reference blocks) a dataset got from
+// the given file. Identical files share their fileId across datasets, hence the dataset filter
+func DeleteVariantsByFileId(cfg *models.Config, es *elasticsearch.Client, dataset string, fileId string) (map[string]interface{}, error) {
+	return deleteVariantsByQuery(cfg, es, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []map[string]interface{}{
+				{"term": map[string]interface{}{"dataset.keyword": dataset}},
+				{"term": map[string]interface{}{"fileId.keyword": fileId}},
+			},
+		},
+	})
+}
+
+// DeleteVariantsOfReplacedFiles removes the variants (and gVCF reference blocks) of a dataset
+// coming from any of the given files and indexed before 'before', i.e. when a new version of
+// these files began to be ingested. Calls overwritten by the new version are therefore kept
+func DeleteVariantsOfReplacedFiles(cfg *models.Config, es *elasticsearch.Client, dataset string, fileIds []string, before time.Time) (map[string]interface{}, error) {
+	// make the latest versions of the overwritten calls visible to the deletion
+	refreshRes, refreshErr := es.Indices.Refresh(
+		es.Indices.Refresh.WithIndex(wildcardVariantsIndex, wildcardCoverageIndex),
+	)
+	if refreshErr != nil {
+		fmt.Printf("Error getting response: %s\n", refreshErr)
+		return nil, refreshErr
+	}
+	refreshRes.Body.Close()
+
+	return deleteVariantsByQuery(cfg, es, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []map[string]interface{}{
+				{"match": map[string]interface{}{"dataset": dataset}},
+				{"terms": map[string]interface{}{"fileId.keyword": fileIds}},
+				{"range": map[string]interface{}{"createdTime": map[string]interface{}{"lt": before}}},
+			},
+		},
+	})
+}
+
+// StartDeletingVariantsByFileId starts deleting, in the background, the variants (and
+// gVCF reference blocks) of a dataset coming from the given file. Returns the deletion's task id
+func StartDeletingVariantsByFileId(cfg *models.Config, es *elasticsearch.Client, dataset string, fileId string) (string, error) {
+	return startDeletingVariantsByQuery(cfg, es, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []map[string]interface{}{
+				{"term": map[string]interface{}{"dataset.keyword": dataset}},
+				{"term": map[string]interface{}{"fileId.keyword": fileId}},
+			},
+		},
+	})
+}
+
+// StartDeletingVariantsBySampleId starts deleting, in the background, all the calls (and gVCF
+// reference blocks) of a sample across a dataset. Returns the deletion's task id
+func StartDeletingVariantsBySampleId(cfg *models.Config, es *elasticsearch.Client, dataset string, sampleId string) (string, error) {
+	// sample ids are indexed lowercased
+	sampleId = strings.ToLower(sampleId)
+
+	return startDeletingVariantsByQuery(cfg, es, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []map[string]interface{}{
+				{"term": map[string]interface{}{"dataset.keyword": dataset}},
+				{
+					"bool": map[string]interface{}{
+						"should": []map[string]interface{}{
+							{"term": map[string]interface{}{"sample.id.keyword": sampleId}},
+							{"term": map[string]interface{}{"sampleId.keyword": sampleId}},
+						},
+						"minimum_should_match": 1,
+					},
+				},
 			},
 		},
+	})
+}
+
+// StartDeletingVariantsInRegion starts deleting, in the background, the variants of a dataset
+// found on a chromosome between the given bounds (0 = unbounded), along with the gVCF reference
+// blocks lying in that region. Returns the deletion's task id
+func StartDeletingVariantsInRegion(cfg *models.Config, es *elasticsearch.Client, dataset string,
+	chromosome string, lowerBound int, upperBound int, rangeMode c.RangeMode) (string, error) {
+
+	// variants, as they'd be found by a query
+	variantFilter := addPositionBoundsToRangeMapSlice(lowerBound, upperBound, rangeMode, []map[string]interface{}{
+		{"exists": map[string]interface{}{"field": "pos"}},
+	})
+
+	// reference blocks
+	coverageFilter := []map[string]interface{}{
+		{"exists": map[string]interface{}{"field": "start"}},
+	}
+	if upperBound > 0 {
+		coverageFilter = append(coverageFilter, map[string]interface{}{
+			"range": map[string]interface{}{"start": map[string]interface{}{"lte": upperBound}},
+		})
+	}
+	if lowerBound > 0 {
+		field := "start"
+		if rangeMode == rm.OVERLAPS {
+			field = "end"
+		}
+		coverageFilter = append(coverageFilter, map[string]interface{}{
+			"range": map[string]interface{}{field: map[string]interface{}{"gte": lowerBound}},
+		})
 	}
 
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		log.Fatalf("Error encoding query: %s\n", query)
+	return startDeletingVariantsByQuery(cfg, es, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []map[string]interface{}{
+				{"term": map[string]interface{}{"dataset.keyword": dataset}},
+				{"term": map[string]interface{}{"chrom.keyword": chromosome}},
+				{
+					"bool": map[string]interface{}{
+						"should": []map[string]interface{}{
+							{"bool": map[string]interface{}{"filter": variantFilter}},
+							{"bool": map[string]interface{}{"filter": coverageFilter}},
+						},
+						"minimum_should_match": 1,
+					},
+				},
+			},
+		},
+	})
+}
+
+// GetDeletionTask reports on the progress of a deletion started in the background
+func GetDeletionTask(cfg *models.Config, es *elasticsearch.Client, taskId string) (*dtos.DeletionTaskDto, error) {
+	if cfg.Debug {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	res, err := es.Tasks.Get(taskId)
+	if err != nil {
+		fmt.Printf("Error getting response: %s\n", err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to get deletion task %s : got '%s'", taskId, res.Status())
+	}
+
+	var result struct {
+		Completed bool `json:"completed"`
+		Task      struct {
+			Status struct {
+				Total            int64 `json:"total"`
+				Deleted          int64 `json:"deleted"`
+				VersionConflicts int64 `json:"version_conflicts"`
+				ThrottledMillis  int64 `json:"throttled_millis"`
+			} `json:"status"`
+		} `json:"task"`
+		Response struct {
+			Failures []map[string]interface{} `json:"failures"`
+		} `json:"response"`
+		Error map[string]interface{} `json:"error"`
+	}
+	if umErr := json.NewDecoder(res.Body).Decode(&result); umErr != nil {
+		fmt.Printf("Error unmarshalling deletion task response: %s\n", umErr)
+		return nil, umErr
+	}
+
+	task := &dtos.DeletionTaskDto{
+		TaskId:           taskId,
+		Completed:        result.Completed,
+		Total:            result.Task.Status.Total,
+		Deleted:          result.Task.Status.Deleted,
+		VersionConflicts: result.Task.Status.VersionConflicts,
+		ThrottledMillis:  result.Task.Status.ThrottledMillis,
+	}
+	if result.Error != nil {
+		task.Error = fmt.Sprint(result.Error["reason"])
+	} else if len(result.Response.Failures) > 0 {
+		task.Error = fmt.Sprintf("%d document(s) failed to be deleted", len(result.Response.Failures))
+	}
+	return task, nil
+}
+
+// -- internal use only --
+func startDeletingVariantsByQuery(cfg *models.Config, es *elasticsearch.Client, query map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"query": query}); err != nil {
+		fmt.Printf("Error encoding query: %s\n", err)
+		return "", err
 	}
 
 	if cfg.Debug {
@@ -771,42 +929,80 @@ func DeleteVariantsByDatasetId(cfg *models.Config, es *elasticsearch.Client, dat
 		fmt.Println(myString)
 	}
 
-	// Perform the delete request.
+	// Start the delete task; its progress is then tracked by id
+	options := []func(*esapi.DeleteByQueryRequest){
+		es.DeleteByQuery.WithConflicts("proceed"),
+		es.DeleteByQuery.WithRefresh(true),
+		es.DeleteByQuery.WithWaitForCompletion(false),
+	}
+	if cfg.Elasticsearch.DeletionRequestsPerSecond > 0 {
+		// leave room for concurrent queries
+		options = append(options, es.DeleteByQuery.WithRequestsPerSecond(cfg.Elasticsearch.DeletionRequestsPerSecond))
+	}
+
 	deleteRes, deleteErr := es.DeleteByQuery(
-		[]string{wildcardVariantsIndex},
+		[]string{wildcardVariantsIndex, wildcardCoverageIndex},
 		bytes.NewReader(buf.Bytes()),
+		options...,
 	)
 	if deleteErr != nil {
 		fmt.Printf("Error getting response: %s\n", deleteErr)
-		return nil, deleteErr
+		return "", deleteErr
 	}
-
 	defer deleteRes.Body.Close()
 
-	resultString := deleteRes.String()
+	if deleteRes.IsError() {
+		return "", fmt.Errorf("failed to start deleting variants : got '%s'", deleteRes.Status())
+	}
+
+	var result struct {
+		Task string `json:"task"`
+	}
+	if umErr := json.NewDecoder(deleteRes.Body).Decode(&result); umErr != nil {
+		fmt.Printf("Error unmarshalling variant deletion response: %s\n", umErr)
+		return "", umErr
+	}
+	return result.Task, nil
+}
+
+func deleteVariantsByQuery(cfg *models.Config, es *elasticsearch.Client, query map[string]interface{}) (map[string]interface{}, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"query": query}); err != nil {
+		fmt.Printf("Error encoding query: %s\n", err)
+		return nil, err
+	}
+
 	if cfg.Debug {
-		fmt.Println(resultString)
+		// view the outbound elasticsearch query
+		myString := string(buf.Bytes()[:])
+		fmt.Println(myString)
 	}
 
-	// Prepare an empty interface
-	result := make(map[string]interface{})
+	// Perform the delete request.
+	deleteRes, deleteErr := es.DeleteByQuery(
+		[]string{wildcardVariantsIndex, wildcardCoverageIndex},
+		bytes.NewReader(buf.Bytes()),
+		es.DeleteByQuery.WithConflicts("proceed"),
+		es.DeleteByQuery.WithRefresh(true),
+	)
+	if deleteErr != nil {
+		fmt.Printf("Error getting response: %s\n", deleteErr)
+		return nil, deleteErr
+	}
+	defer deleteRes.Body.Close()
 
-	// Unmarshal or Decode the JSON to the empty interface.
-	// Known bug: response comes back with a preceding '[200 OK] ' which needs trimming
-	bracketString, jsonBodyString := utils.GetLeadingStringInBetweenSquareBrackets(resultString)
-	if !strings.Contains(bracketString, "200") {
-		return nil, fmt.Errorf("failed to get documents by id : got '%s'", bracketString)
+	if deleteRes.IsError() {
+		return nil, fmt.Errorf("failed to delete variants : got '%s'", deleteRes.Status())
 	}
-	umErr := json.Unmarshal([]byte(jsonBodyString), &result)
-	if umErr != nil {
+
+	result := make(map[string]interface{})
+	if umErr := json.NewDecoder(deleteRes.Body).Decode(&result); umErr != nil {
 		fmt.Printf("Error unmarshalling variant deletion response: %s\n", umErr)
 		return nil, umErr
 	}
-
 	return result, nil
 }
 
-// -- internal use only --
 func addAllelesToShouldMap(alleles []string, genotype c.GenotypeQuery, allelesShouldMap []map[string]interface{}) ([]map[string]interface{}, int) {
 	minimumShouldMatch := 0
 
@@ -837,7 +1033,34 @@ func addAllelesToShouldMap(alleles []string, genotype c.GenotypeQuery, allelesSh
 				allelesShouldMap = append(allelesShouldMap, allelesShouldMapBuilder(alleles[0], "AND", alleles[1]))
 				allelesShouldMap = append(allelesShouldMap, allelesShouldMapBuilder(alleles[1], "AND", alleles[0]))
 			}
-			// TODO: triploid ?
+		default:
+			// polyploid case
+			// - the call should be of the ploidy of the query, and hold each queried
+			//   allele as many times as queried (in any order), i.e. A,A,T doesn't match A/T/T.
+			// - alleles holding wildcards ('N') are only required to be present once,
+			//   as the alleles they stand for may be distinct
+			filters := []map[string]interface{}{{
+				"term": map[string]interface{}{"sample.variation.genotype.ploidy": len(alleles)},
+			}}
+			concreteAlleles := []string{}
+			for _, allele := range alleles {
+				if strings.Contains(allele, "?") {
+					filters = append(filters, map[string]interface{}{
+						"wildcard": map[string]interface{}{"sample.variation.alleleOccurrences": allele + ":1"},
+					})
+				} else {
+					concreteAlleles = append(concreteAlleles, allele)
+				}
+			}
+			for _, occurrence := range utils.NumberOccurrences(concreteAlleles) {
+				filters = append(filters, map[string]interface{}{
+					"term": map[string]interface{}{"sample.variation.alleleOccurrences": occurrence},
+				})
+			}
+			allelesShouldMap = append(allelesShouldMap, map[string]interface{}{
+				"bool": map[string]interface{}{
+					"filter": filters,
+				}})
 		}
 		minimumShouldMatch = 1
 	}
@@ -865,10 +1088,20 @@ func addZygosityToMustMap(genotype c.GenotypeQuery, mustMap []map[string]interfa
 		zygosityMatchMap["sample.variation.genotype.zygosity"] = map[string]interface{}{
 			"query": z.Alternate,
 		}
-	// Diploid
+	// Diploid or higher
 	case gq.HETEROZYGOUS:
+		// - includes calls made of distinct alternate alleles only (i.e. 1/2)
+		return append(mustMap, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"sample.variation.genotype.zygosity": []c.Zygosity{z.Heterozygous, z.HeterozygousAlternate},
+			},
+		})
+
+	case gq.HETEROZYGOUS_ALTERNATE:
+		// - calls ingested before this zygosity existed were classified as
+		//   heterozygous, and are only matched once their file is re-ingested
 		zygosityMatchMap["sample.variation.genotype.zygosity"] = map[string]interface{}{
-			"query": z.Heterozygous,
+			"query": z.HeterozygousAlternate,
 		}
 
 	case gq.HOMOZYGOUS_REFERENCE:
@@ -891,3 +1124,109 @@ func addZygosityToMustMap(genotype c.GenotypeQuery, mustMap []map[string]interfa
 
 	return mustMap
 }
+
+// addInfoBoundsToRangeMapSlice bounds numeric INFO fields, by field id. A field holding
+// several values (i.e. AF, one per alternate allele) matches if any of them is in bounds
+func addInfoBoundsToRangeMapSlice(minInfo map[string]float64, maxInfo map[string]float64, rangeMapSlice []map[string]interface{}) []map[string]interface{} {
+	bounds := map[string]map[string]interface{}{}
+	for id, min := range minInfo {
+		if bounds[id] == nil {
+			bounds[id] = map[string]interface{}{}
+		}
+		bounds[id]["gte"] = min
+	}
+	for id, max := range maxInfo {
+		if bounds[id] == nil {
+			bounds[id] = map[string]interface{}{}
+		}
+		bounds[id]["lte"] = max
+	}
+
+	for id, b := range bounds {
+		rangeMapSlice = append(rangeMapSlice, map[string]interface{}{
+			"range": map[string]interface{}{
+				"infoTyped." + id: b,
+			},
+		})
+	}
+	return rangeMapSlice
+}
+
+// addPositionBoundsToRangeMapSlice matches variants starting within the bounds or, in
+// 'overlaps' mode, spanning any part of them (0 = no bound). Documents ingested prior
+// to the 'end' coordinate being stored are matched on their position only
+func addPositionBoundsToRangeMapSlice(lowerBound int, upperBound int, rangeMode c.RangeMode, rangeMapSlice []map[string]interface{}) []map[string]interface{} {
+	// TODO: make upperbound and lowerbound nilable, somehow?
+	if upperBound > 0 {
+		rangeMapSlice = append(rangeMapSlice, map[string]interface{}{
+			"range": map[string]interface{}{
+				"pos": map[string]interface{}{
+					"lte": upperBound,
+				},
+			},
+		})
+	}
+
+	if lowerBound > 0 {
+		startsInRange := map[string]interface{}{
+			"range": map[string]interface{}{
+				"pos": map[string]interface{}{
+					"gte": lowerBound,
+				},
+			},
+		}
+
+		if rangeMode != rm.OVERLAPS {
+			rangeMapSlice = append(rangeMapSlice, startsInRange)
+		} else {
+			rangeMapSlice = append(rangeMapSlice, map[string]interface{}{
+				"bool": map[string]interface{}{
+					"should": []map[string]interface{}{
+						{
+							"range": map[string]interface{}{
+								"end": map[string]interface{}{
+									"gte": lowerBound,
+								},
+							},
+						},
+						{
+							"bool": map[string]interface{}{
+								"must_not": map[string]interface{}{
+									"exists": map[string]interface{}{"field": "end"},
+								},
+								"must": startsInRange,
+							},
+						},
+					},
+					"minimum_should_match": 1,
+				},
+			})
+		}
+	}
+
+	return rangeMapSlice
+}
+
+// addQualityThresholdsToRangeMapSlice leaves out calls below the given
+// thresholds (0 = no threshold). Calls lacking the field altogether are left out too
+func addQualityThresholdsToRangeMapSlice(minGenotypeQuality int, minReadDepth int, minQual float64, rangeMapSlice []map[string]interface{}) []map[string]interface{} {
+	for _, t := range []struct {
+		field     string
+		threshold float64
+	}{
+		{"sample.variation.genotypeQuality", float64(minGenotypeQuality)},
+		{"sample.variation.readDepth", float64(minReadDepth)},
+		{"qual", minQual},
+	} {
+		if t.threshold > 0 {
+			rangeMapSlice = append(rangeMapSlice, map[string]interface{}{
+				"range": map[string]interface{}{
+					t.field: map[string]interface{}{
+						"gte": t.threshold,
+					},
+				},
+			})
+		}
+	}
+	return rangeMapSlice
+}
@@ -0,0 +1,42 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+
+	"gohan/api/models"
+	ab "gohan/api/models/constants/archive-backend"
+	"gohan/api/services/drs"
+)
+
+// FileArchive keeps the files variants are ingested from, along with their indexes
+type FileArchive interface {
+	// Store archives a file of the API-DRS bridge directory, returning
+	// a URI telling which backend holds it, and under which id
+	Store(ctx context.Context, request StoreRequest) (string, error)
+}
+
+type StoreRequest struct {
+	FileName   string // relative to the bridge directory
+	ProjectId  string
+	DatasetId  string
+	AuthHeader string // forwarded to backends requiring authorization
+}
+
+// NewFileArchive sets up the backend chosen by configuration
+func NewFileArchive(cfg *models.Config, drsClient *drs.Client) (FileArchive, error) {
+	backend, err := ab.CastToArchiveBackend(cfg.Archive.Backend)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive backend '%s' - please provide 'drs' or 'local'", cfg.Archive.Backend)
+	}
+
+	switch backend {
+	case ab.LOCAL:
+		if cfg.Archive.LocalPath == "" {
+			return nil, fmt.Errorf("the 'local' archive backend requires GOHAN_ARCHIVE_LOCAL_PATH")
+		}
+		return &LocalArchive{Root: cfg.Archive.LocalPath, BridgeDirectory: cfg.Api.BridgeDirectory}, nil
+	default:
+		return &DrsArchive{Client: drsClient, BridgeDirectory: cfg.Drs.BridgeDirectory}, nil
+	}
+}
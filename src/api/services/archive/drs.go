@@ -0,0 +1,30 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+
+	drsModels "gohan/api/models/drs"
+	"gohan/api/services/drs"
+)
+
+// DrsArchive ingests files into DRS, which reads them from its end of the API-DRS bridge directory
+type DrsArchive struct {
+	Client          *drs.Client
+	BridgeDirectory string // as seen by DRS
+}
+
+func (da *DrsArchive) Store(ctx context.Context, request StoreRequest) (string, error) {
+	object, err := da.Client.Ingest(ctx, drsModels.IngestRequest{
+		Path:      fmt.Sprintf("%s/%s", da.BridgeDirectory, request.FileName),
+		DatasetId: request.DatasetId,
+		ProjectId: request.ProjectId,
+		DataType:  "variant",
+	}, request.AuthHeader)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("File %s ingested into DRS as %s\n", request.FileName, object.Id)
+	return da.Client.Uri(object.Id), nil
+}
@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+const (
+	LocalScheme = "local"
+
+	// files are addressed by the SHA-256 of their content
+	localAlgorithm = "sha256"
+)
+
+// LocalArchive stores files in a local directory, under <root>/sha256/<first 2 digits>/<digest>,
+// such that storing the same file twice keeps a single copy
+type LocalArchive struct {
+	Root            string
+	BridgeDirectory string
+}
+
+func (la *LocalArchive) Store(ctx context.Context, request StoreRequest) (string, error) {
+	source, err := os.Open(path.Join(la.BridgeDirectory, request.FileName))
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	directory := path.Join(la.Root, localAlgorithm)
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(directory, ".incoming-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), source); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to copy %s to the archive: %w", request.FileName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	destination := la.Path(digest)
+	if _, err := os.Stat(destination); err == nil {
+		// already archived
+		return localUri(digest), nil
+	}
+	if err := os.MkdirAll(path.Dir(destination), 0700); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), destination); err != nil {
+		return "", err
+	}
+	return localUri(digest), nil
+}
+
+// Path is where the file of the given digest is kept
+func (la *LocalArchive) Path(digest string) string {
+	return path.Join(la.Root, localAlgorithm, digest[:2], digest)
+}
+
+func localUri(digest string) string {
+	return fmt.Sprintf("%s://%s/%s", LocalScheme, localAlgorithm, digest)
+}
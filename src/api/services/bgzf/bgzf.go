@@ -0,0 +1,89 @@
+package bgzf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	MaxBlockSize = 65536 // compressed, header and footer included
+
+	// uncompressed bytes per block, leaving room for incompressible data
+	MaxBlockDataSize = 0xff00
+
+	fixedHeaderSize = 12 // up to XLEN
+	blockHeaderSize = 18 // with the 'BC' subfield alone, as written
+	blockFooterSize = 8  // CRC32 and ISIZE
+)
+
+// the empty block marking the end of a BGZF file
+var eofBlock = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x06, 0x00, 0x42, 0x43, 0x02, 0x00,
+	0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+// BGZF ("blocked gzip") is the flavour of gzip tabix indexes rely on : a series of gzip members
+// of up to 64KiB each, recording their compressed size in a 'BC' extra subfield, such that any
+// position in the uncompressed data can be addressed by a virtual offset, made of the offset
+// of its block in the file (upper 48 bits) and its offset within the block (lower 16 bits)
+type VirtualOffset uint64
+
+func NewVirtualOffset(blockOffset int64, withinBlock int) VirtualOffset {
+	return VirtualOffset(uint64(blockOffset)<<16 | uint64(withinBlock))
+}
+
+// BlockOffset is the offset of the block in the file
+func (v VirtualOffset) BlockOffset() int64 {
+	return int64(v >> 16)
+}
+
+// WithinBlock is the offset in the uncompressed data of the block
+func (v VirtualOffset) WithinBlock() int {
+	return int(v & 0xffff)
+}
+
+// IsBgzf tells whether 'r' starts with a BGZF block, rather than any other gzip member
+// (or anything else). Reads up to the first 18 bytes
+func IsBgzf(r io.Reader) (bool, error) {
+	header := make([]byte, blockHeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	if n < fixedHeaderSize || !isGzipMember(header) || header[3]&flagExtra == 0 {
+		return false, nil
+	}
+
+	// the 'BC' subfield may not come first
+	xlen := int(binary.LittleEndian.Uint16(header[10:12]))
+	extra := make([]byte, xlen)
+	copied := copy(extra, header[fixedHeaderSize:n])
+	if _, err := io.ReadFull(r, extra[copied:]); err != nil {
+		return false, nil
+	}
+	_, found := blockSize(extra)
+	return found, nil
+}
+
+const flagExtra = 1 << 2 // FLG.FEXTRA
+
+func isGzipMember(header []byte) bool {
+	return bytes.HasPrefix(header, []byte{0x1f, 0x8b, 0x08})
+}
+
+// blockSize finds the 'BC' subfield of a gzip member's extra field,
+// holding the total size of the block, minus 1
+func blockSize(extra []byte) (int, bool) {
+	for len(extra) >= 4 {
+		slen := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if extra[0] == 'B' && extra[1] == 'C' && slen == 2 && len(extra) >= 6 {
+			return int(binary.LittleEndian.Uint16(extra[4:6])) + 1, true
+		}
+		if len(extra) < 4+slen {
+			break
+		}
+		extra = extra[4+slen:]
+	}
+	return 0, false
+}
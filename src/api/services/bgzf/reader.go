@@ -0,0 +1,166 @@
+package bgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Reader decompresses BGZF blocks, keeping track of the virtual offset of what it reads
+type Reader struct {
+	r io.Reader
+
+	blockOffset int64 // of the current block
+	nextOffset  int64 // of the next block
+	data        []byte
+	pos         int // within 'data'
+
+	inflater io.ReadCloser
+	raw      []byte
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:        r,
+		inflater: flate.NewReader(bytes.NewReader(nil)),
+		raw:      make([]byte, MaxBlockSize),
+		data:     make([]byte, 0, MaxBlockSize),
+	}
+}
+
+func (br *Reader) Read(p []byte) (int, error) {
+	for br.pos == len(br.data) {
+		if err := br.readBlock(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, br.data[br.pos:])
+	br.pos += n
+	return n, nil
+}
+
+// ReadLine reads up to the next '\n' (left out), across blocks if need be.
+// Returns io.EOF once there is nothing left to read
+func (br *Reader) ReadLine() ([]byte, error) {
+	var line []byte
+	for {
+		for br.pos == len(br.data) {
+			if err := br.readBlock(); err != nil {
+				if err == io.EOF && line != nil {
+					return line, nil
+				}
+				return nil, err
+			}
+		}
+
+		rest := br.data[br.pos:]
+		if idx := bytes.IndexByte(rest, '\n'); idx >= 0 {
+			line = append(line, rest[:idx]...)
+			br.pos += idx + 1
+			return line, nil
+		}
+		line = append(line, rest...)
+		br.pos = len(br.data)
+	}
+}
+
+// VirtualOffset is where the next byte read will be found
+func (br *Reader) VirtualOffset() VirtualOffset {
+	if br.pos == len(br.data) {
+		return NewVirtualOffset(br.nextOffset, 0)
+	}
+	return NewVirtualOffset(br.blockOffset, br.pos)
+}
+
+// Seek moves to the given virtual offset, provided the underlying reader is an io.Seeker
+func (br *Reader) Seek(offset VirtualOffset) error {
+	seeker, ok := br.r.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("bgzf: cannot seek")
+	}
+	if _, err := seeker.Seek(offset.BlockOffset(), io.SeekStart); err != nil {
+		return err
+	}
+
+	br.nextOffset = offset.BlockOffset()
+	br.data = br.data[:0]
+	br.pos = 0
+	if err := br.readBlock(); err != nil {
+		return err
+	}
+	if offset.WithinBlock() > len(br.data) {
+		return fmt.Errorf("bgzf: offset %d is past the end of the block at offset %d", offset.WithinBlock(), offset.BlockOffset())
+	}
+	br.pos = offset.WithinBlock()
+	return nil
+}
+
+func (br *Reader) readBlock() error {
+	offset := br.nextOffset
+
+	header := br.raw[:fixedHeaderSize]
+	if n, err := io.ReadFull(br.r, header); err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("bgzf: block at offset %d is truncated (%d bytes)", offset, n)
+		}
+		return err
+	}
+	if !isGzipMember(header) {
+		return fmt.Errorf("bgzf: block at offset %d is not a gzip member", offset)
+	}
+	if header[3]&flagExtra == 0 {
+		return fmt.Errorf("bgzf: block at offset %d is a gzip member, but not a BGZF block (no extra field)", offset)
+	}
+
+	xlen := int(binary.LittleEndian.Uint16(header[10:12]))
+	if fixedHeaderSize+xlen+blockFooterSize > MaxBlockSize {
+		return fmt.Errorf("bgzf: block at offset %d has an invalid extra field (%d bytes)", offset, xlen)
+	}
+	extra := br.raw[fixedHeaderSize : fixedHeaderSize+xlen]
+	if _, err := io.ReadFull(br.r, extra); err != nil {
+		return fmt.Errorf("bgzf: block at offset %d is truncated", offset)
+	}
+	size, found := blockSize(extra)
+	if !found {
+		return fmt.Errorf("bgzf: block at offset %d is a gzip member, but not a BGZF block (no 'BC' subfield)", offset)
+	}
+	if size < fixedHeaderSize+xlen+blockFooterSize {
+		return fmt.Errorf("bgzf: block at offset %d has an invalid size (%d bytes)", offset, size)
+	}
+
+	rest := br.raw[fixedHeaderSize+xlen : size]
+	if _, err := io.ReadFull(br.r, rest); err != nil {
+		return fmt.Errorf("bgzf: block at offset %d is truncated", offset)
+	}
+	compressed := rest[:len(rest)-blockFooterSize]
+	footer := rest[len(rest)-blockFooterSize:]
+	expectedCrc := binary.LittleEndian.Uint32(footer[0:4])
+	expectedSize := int(binary.LittleEndian.Uint32(footer[4:8]))
+	if expectedSize > MaxBlockSize {
+		return fmt.Errorf("bgzf: block at offset %d claims %d uncompressed bytes, more than a block may hold", offset, expectedSize)
+	}
+
+	if err := br.inflater.(flate.Resetter).Reset(bytes.NewReader(compressed), nil); err != nil {
+		return err
+	}
+	data := br.data[:expectedSize]
+	if _, err := io.ReadFull(br.inflater, data); err != nil {
+		return fmt.Errorf("bgzf: block at offset %d failed to decompress: %w", offset, err)
+	}
+	if crc32.ChecksumIEEE(data) != expectedCrc {
+		return fmt.Errorf("bgzf: block at offset %d is corrupted (CRC mismatch)", offset)
+	}
+
+	br.blockOffset = offset
+	br.nextOffset = offset + int64(size)
+	br.data = data
+	br.pos = 0
+	return nil
+}
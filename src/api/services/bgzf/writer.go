@@ -0,0 +1,121 @@
+package bgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Writer compresses what's written to it as BGZF blocks
+type Writer struct {
+	w      io.Writer
+	offset int64 // compressed bytes written so far, i.e. the offset of the next block
+
+	pending    []byte // not compressed yet
+	compressed bytes.Buffer
+	deflater   *flate.Writer
+	closed     bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	deflater, _ := flate.NewWriter(nil, flate.DefaultCompression)
+	return &Writer{
+		w:        w,
+		pending:  make([]byte, 0, MaxBlockDataSize),
+		deflater: deflater,
+	}
+}
+
+func (bw *Writer) Write(p []byte) (int, error) {
+	if bw.closed {
+		return 0, fmt.Errorf("bgzf: write to a closed writer")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := copy(bw.pending[len(bw.pending):MaxBlockDataSize], p)
+		bw.pending = bw.pending[:len(bw.pending)+n]
+		p = p[n:]
+		written += n
+
+		if len(bw.pending) == MaxBlockDataSize {
+			if err := bw.Flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// VirtualOffset is where the next byte written will be found
+func (bw *Writer) VirtualOffset() VirtualOffset {
+	return NewVirtualOffset(bw.offset, len(bw.pending))
+}
+
+// Flush compresses what was written so far into a block of its own
+func (bw *Writer) Flush() error {
+	if len(bw.pending) == 0 {
+		return nil
+	}
+	if err := bw.writeBlock(bw.pending); err != nil {
+		return err
+	}
+	bw.pending = bw.pending[:0]
+	return nil
+}
+
+// Close flushes what's left, and marks the end of the file. The underlying writer is left open
+func (bw *Writer) Close() error {
+	if bw.closed {
+		return nil
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	bw.closed = true
+
+	n, err := bw.w.Write(eofBlock)
+	bw.offset += int64(n)
+	return err
+}
+
+func (bw *Writer) writeBlock(data []byte) error {
+	bw.compressed.Reset()
+	bw.deflater.Reset(&bw.compressed)
+	if _, err := bw.deflater.Write(data); err != nil {
+		return err
+	}
+	if err := bw.deflater.Close(); err != nil {
+		return err
+	}
+
+	size := blockHeaderSize + bw.compressed.Len() + blockFooterSize
+	if size > MaxBlockSize {
+		// barely compressible data : split it
+		half := len(data) / 2
+		if err := bw.writeBlock(data[:half]); err != nil {
+			return err
+		}
+		return bw.writeBlock(data[half:])
+	}
+
+	block := make([]byte, 0, size)
+	block = append(block,
+		0x1f, 0x8b, 0x08, flagExtra, // ID1, ID2, CM, FLG
+		0, 0, 0, 0, // MTIME
+		0, 0xff, // XFL, OS (unknown)
+		6, 0, // XLEN
+		'B', 'C', 2, 0, // the 'BC' subfield...
+	)
+	block = binary.LittleEndian.AppendUint16(block, uint16(size-1)) // ... holding BSIZE
+	block = append(block, bw.compressed.Bytes()...)
+	block = binary.LittleEndian.AppendUint32(block, crc32.ChecksumIEEE(data))
+	block = binary.LittleEndian.AppendUint32(block, uint32(len(data)))
+
+	n, err := bw.w.Write(block)
+	bw.offset += int64(n)
+	return err
+}
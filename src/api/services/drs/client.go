@@ -0,0 +1,352 @@
+package drs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gohan/api/models"
+	drsModels "gohan/api/models/drs"
+
+	"github.com/cenkalti/backoff"
+)
+
+const (
+	Scheme = "drs"
+
+	objectsPath = "/ga4gh/drs/v1/objects"
+
+	defaultTimeout        = 30 * time.Second
+	defaultInitialBackoff = time.Second
+)
+
+var ErrNotFound = errors.New("DRS object not found")
+
+// StatusError is an unexpected status DRS responded with
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("DRS responded '%d %s'", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("DRS responded '%d %s': %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *StatusError) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Client talks to DRS, retrying what fails transiently (network errors, 408, 429 and 5xx
+// responses) with an exponential backoff. Every attempt is bound by the configured timeout.
+// Requests which aren't idempotent (i.e. ingesting a file) are only retried when DRS
+// certainly didn't process them, such that objects don't get ingested twice
+type Client struct {
+	url      string
+	host     string
+	username string
+	password string
+
+	timeout        time.Duration
+	maxRetries     uint64
+	initialBackoff time.Duration
+
+	httpClient *http.Client
+}
+
+func NewClient(cfg *models.Config) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Debug {
+		// self-signed certificates are expected in development,
+		// which only concerns requests made to DRS
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	baseUrl := strings.TrimSuffix(cfg.Drs.Url, "/")
+	host := baseUrl
+	if parsed, err := url.Parse(baseUrl); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+
+	c := &Client{
+		url:            baseUrl,
+		host:           host,
+		username:       cfg.Drs.Username,
+		password:       cfg.Drs.Password,
+		timeout:        cfg.Drs.Timeout,
+		maxRetries:     cfg.Drs.MaxRetries,
+		initialBackoff: cfg.Drs.InitialBackoff,
+		httpClient:     &http.Client{Transport: transport},
+	}
+	if c.timeout <= 0 {
+		c.timeout = defaultTimeout
+	}
+	if c.initialBackoff <= 0 {
+		c.initialBackoff = defaultInitialBackoff
+	}
+	return c
+}
+
+// Ingest has DRS ingest a file of its end of the API-DRS bridge directory
+func (c *Client) Ingest(ctx context.Context, request drsModels.IngestRequest, authHeader string) (*drsModels.Object, error) {
+	form := url.Values{}
+	form.Add("path", request.Path)
+	form.Add("dataset_id", request.DatasetId)
+	form.Add("project_id", request.ProjectId)
+	form.Add("data_type", request.DataType)
+
+	var object drsModels.Object
+	err := c.do(ctx, http.MethodPost, "/ingest", []byte(form.Encode()), "application/x-www-form-urlencoded", authHeader, http.StatusCreated, &object)
+	if err != nil {
+		return nil, err
+	}
+	if object.Id == "" {
+		return nil, fmt.Errorf("DRS ingested %s, but responded with no object id", request.Path)
+	}
+	return &object, nil
+}
+
+// GetObject fetches an object's metadata; wraps ErrNotFound if DRS doesn't know it
+func (c *Client) GetObject(ctx context.Context, id string, authHeader string) (*drsModels.Object, error) {
+	var object drsModels.Object
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/%s", objectsPath, url.PathEscape(id)), nil, "", authHeader, http.StatusOK, &object)
+	if err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+// GetAccessUrl fetches the URL the bytes of an object can be read from, for access methods providing an access id
+func (c *Client) GetAccessUrl(ctx context.Context, id string, accessId string, authHeader string) (*drsModels.AccessUrl, error) {
+	var accessUrl drsModels.AccessUrl
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/%s/access/%s", objectsPath, url.PathEscape(id), url.PathEscape(accessId)), nil, "", authHeader, http.StatusOK, &accessUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &accessUrl, nil
+}
+
+// Uri refers to an object of this DRS instance, following the hostname-based
+// DRS URI scheme, i.e. drs://<host>/<object id>
+func (c *Client) Uri(id string) string {
+	return fmt.Sprintf("%s://%s/%s", Scheme, c.host, id)
+}
+
+// ObjectId finds the id of the object a DRS URI of this DRS instance refers to. Plain ids, as
+// recorded before ingested files were referred to by URI, are returned as is. URIs of other
+// DRS instances aren't, as their ids may well refer to different objects here
+func (c *Client) ObjectId(uri string) (string, bool) {
+	if !strings.Contains(uri, "://") {
+		return uri, uri != ""
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != Scheme || parsed.Host != c.host {
+		return "", false
+	}
+	id := strings.TrimPrefix(parsed.Path, "/")
+	return id, id != "" && !strings.Contains(id, "/")
+}
+
+// Open streams the bytes of an object, from the first of its access methods served over HTTP(S).
+// The timeout bounds the wait for DRS to respond, rather than the reading of the bytes
+func (c *Client) Open(ctx context.Context, object *drsModels.Object, authHeader string) (io.ReadCloser, error) {
+	accessUrl, err := c.resolveAccessUrl(ctx, object, authHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.ReadCloser
+	attempt := func() error {
+		requestCtx, cancel := context.WithCancel(ctx)
+		timer := time.AfterFunc(c.timeout, cancel)
+
+		r, err := http.NewRequestWithContext(requestCtx, http.MethodGet, accessUrl.Url, nil)
+		if err != nil {
+			cancel()
+			return backoff.Permanent(err)
+		}
+		for _, header := range accessUrl.Headers {
+			if name, value, found := strings.Cut(header, ":"); found {
+				r.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+			}
+		}
+		if r.Header.Get("Authorization") == "" && r.URL.Host == c.host {
+			// served by DRS itself
+			c.authorize(r, authHeader)
+		}
+
+		res, err := c.httpClient.Do(r)
+		if !timer.Stop() && err == nil {
+			// timed out as the response came in
+			res.Body.Close()
+			err = context.DeadlineExceeded
+		}
+		if err != nil {
+			cancel()
+			if ctx.Err() != nil {
+				return backoff.Permanent(ctx.Err())
+			}
+			return err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			statusErr := newStatusError(res)
+			res.Body.Close()
+			cancel()
+			if isRetryable(res.StatusCode) {
+				return statusErr
+			}
+			return backoff.Permanent(statusErr)
+		}
+
+		body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+		return nil
+	}
+
+	if err := c.retry(ctx, http.MethodGet, accessUrl.Url, attempt); err != nil {
+		return nil, fmt.Errorf("failed to read DRS object %s: %w", object.Id, err)
+	}
+	return body, nil
+}
+
+func (c *Client) resolveAccessUrl(ctx context.Context, object *drsModels.Object, authHeader string) (*drsModels.AccessUrl, error) {
+	for _, method := range object.AccessMethods {
+		if method.Type != "https" && method.Type != "http" {
+			// i.e. 'file' URLs, only valid from within DRS
+			continue
+		}
+		if method.AccessUrl != nil && method.AccessUrl.Url != "" {
+			return method.AccessUrl, nil
+		}
+		if method.AccessId != "" {
+			return c.GetAccessUrl(ctx, object.Id, method.AccessId, authHeader)
+		}
+	}
+	return nil, fmt.Errorf("DRS object %s can't be read over HTTP(S)", object.Id)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body []byte, contentType string, authHeader string, expectedStatus int, out interface{}) error {
+	idempotent := method != http.MethodPost
+
+	attempt := func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+		r, err := http.NewRequestWithContext(attemptCtx, method, c.url+path, bodyReader)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		r.Header.Set("Accept", "application/json")
+		c.authorize(r, authHeader)
+
+		res, err := c.httpClient.Do(r)
+		if err != nil {
+			if ctx.Err() != nil {
+				return backoff.Permanent(ctx.Err())
+			}
+			if !idempotent && !isConnectionError(err) {
+				// i.e. timed out, DRS may still have processed the request
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != expectedStatus {
+			statusErr := newStatusError(res)
+			if isRetryable(res.StatusCode) && (idempotent || res.StatusCode == http.StatusTooManyRequests) {
+				return statusErr
+			}
+			return backoff.Permanent(statusErr)
+		}
+
+		if out != nil {
+			if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+				return backoff.Permanent(fmt.Errorf("failed to decode the DRS response to %s %s: %w", method, path, err))
+			}
+		}
+		return nil
+	}
+
+	return c.retry(ctx, method, path, attempt)
+}
+
+func (c *Client) retry(ctx context.Context, method string, path string, attempt func() error) error {
+	exponential := backoff.NewExponentialBackOff()
+	exponential.InitialInterval = c.initialBackoff
+	policy := backoff.WithContext(backoff.WithMaxRetries(exponential, c.maxRetries), ctx)
+
+	return backoff.RetryNotify(attempt, policy, func(err error, wait time.Duration) {
+		fmt.Printf("DRS request %s %s failed (%s) -- trying again in %s\n", method, path, err, wait)
+	})
+}
+
+// authorize forwards the authorization of the request DRS is queried for, if any,
+// or falls back on the configured credentials
+func (c *Client) authorize(r *http.Request, authHeader string) {
+	if authHeader != "" {
+		r.Header.Set("Authorization", authHeader)
+	} else if c.username != "" {
+		r.SetBasicAuth(c.username, c.password)
+	}
+}
+
+// cancelOnClose releases the context of a streamed response once it's been read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (cc *cancelOnClose) Close() error {
+	defer cc.cancel()
+	return cc.ReadCloser.Close()
+}
+
+func newStatusError(res *http.Response) *StatusError {
+	statusErr := &StatusError{StatusCode: res.StatusCode}
+
+	content, err := io.ReadAll(io.LimitReader(res.Body, 4096))
+	if err != nil {
+		return statusErr
+	}
+	var drsErr drsModels.Error
+	if json.Unmarshal(content, &drsErr) == nil && drsErr.Msg != "" {
+		statusErr.Message = drsErr.Msg
+	} else {
+		statusErr.Message = strings.TrimSpace(string(content))
+	}
+	return statusErr
+}
+
+// isConnectionError tells whether a request failed before reaching DRS at all
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func isRetryable(statusCode int) bool {
+	return statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests ||
+		statusCode >= http.StatusInternalServerError
+}
@@ -5,22 +5,24 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gohan/api/models"
 	"gohan/api/models/constants"
-	p "gohan/api/models/constants/ploidy"
+	wm "gohan/api/models/constants/write-mode"
 	z "gohan/api/models/constants/zygosity"
 	"gohan/api/models/ingest"
 	"gohan/api/models/ingest/structs"
+	esRepo "gohan/api/repositories/elasticsearch"
+	"gohan/api/services/reference"
+	"gohan/api/services/tabix"
+	"gohan/api/services/vcf"
 	"gohan/api/utils"
-	"io/ioutil"
-	"log"
+	"io"
+	"math"
 	"net/http"
-	"net/url"
 	"os"
-	"os/exec"
 	"path"
 	"strconv"
 	"strings"
@@ -30,7 +32,7 @@ import (
 
 	"gohan/api/models/indexes"
 
-	"github.com/Jeffail/gabs"
+	"github.com/cenkalti/backoff"
 	"github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v7/esutil"
 	"github.com/google/uuid"
@@ -43,15 +45,35 @@ type (
 		IngestRequestChan              chan *ingest.VariantIngestRequest
 		IngestRequestMap               map[string]*ingest.VariantIngestRequest
 		IngestRequestMapMux            sync.RWMutex
+		IngestRequestCancellations     map[string]*ingestionCancellation
+		uploadSessions                 map[string]*uploadSession
+		uploadSessionsMux              sync.Mutex
+		variantIngestJobs              map[string]*ingest.VariantIngestJob
+		variantIngestJobLocks          map[string]*idempotencyKeyLock
+		variantIngestJobsMux           sync.Mutex
 		GeneIngestRequestChan          chan *ingest.GeneIngestRequest
 		GeneIngestRequestMap           map[string]*ingest.GeneIngestRequest
+		GeneIngestRequestMapMux        sync.RWMutex
 		IngestionBulkIndexingCapacity  int
 		IngestionBulkIndexingQueue     chan *structs.IngestionQueueStructure
 		IngestionBulkIndexer           esutil.BulkIndexer
 		GeneIngestionBulkIndexingQueue chan *structs.GeneIngestionQueueStructure
 		GeneIngestionBulkIndexer       esutil.BulkIndexer
 		ConcurrentFileIngestionQueue   chan bool
+		ReferenceGenomes               *reference.Genomes
 		ElasticsearchClient            *elasticsearch.Client
+		Config                         *models.Config
+
+		// requests created from then on belong to this process
+		// and mustn't be mistaken for interrupted ones
+		startedAt time.Time
+	}
+
+	// ingestionCancellation allows for a running or queued
+	// variant ingestion request to be stopped
+	ingestionCancellation struct {
+		cancel   context.CancelFunc
+		rollback bool
 	}
 )
 
@@ -62,13 +84,23 @@ func NewIngestionService(es *elasticsearch.Client, cfg *models.Config) *Ingestio
 		IngestRequestChan:              make(chan *ingest.VariantIngestRequest),
 		IngestRequestMap:               map[string]*ingest.VariantIngestRequest{},
 		IngestRequestMapMux:            sync.RWMutex{},
+		IngestRequestCancellations:     map[string]*ingestionCancellation{},
+		uploadSessions:                 map[string]*uploadSession{},
+		uploadSessionsMux:              sync.Mutex{},
+		variantIngestJobs:              map[string]*ingest.VariantIngestJob{},
+		variantIngestJobLocks:          map[string]*idempotencyKeyLock{},
+		variantIngestJobsMux:           sync.Mutex{},
 		GeneIngestRequestChan:          make(chan *ingest.GeneIngestRequest),
 		GeneIngestRequestMap:           map[string]*ingest.GeneIngestRequest{},
+		GeneIngestRequestMapMux:        sync.RWMutex{},
 		IngestionBulkIndexingCapacity:  cfg.Api.BulkIndexingCap,
 		IngestionBulkIndexingQueue:     make(chan *structs.IngestionQueueStructure, cfg.Api.BulkIndexingCap),
 		GeneIngestionBulkIndexingQueue: make(chan *structs.GeneIngestionQueueStructure, 10),
 		ConcurrentFileIngestionQueue:   make(chan bool, cfg.Api.FileProcessingConcurrencyLevel),
+		ReferenceGenomes:               reference.NewGenomes(cfg.Api.ReferencePath),
 		ElasticsearchClient:            es,
+		Config:                         cfg,
+		startedAt:                      time.Now(),
 	}
 
 	//see: https://www.elastic.co/blog/why-am-i-seeing-bulk-rejections-in-my-elasticsearch-cluster
@@ -101,6 +133,13 @@ func NewIngestionService(es *elasticsearch.Client, cfg *models.Config) *Ingestio
 func (i *IngestionService) Init() {
 	// safeguard to prevent multiple initilizations
 	if !i.Initialized {
+		// reload the history of ingestion requests from the durable
+		// store in the background, as elasticsearch may not be up yet
+		go i.restoreIngestionRequests()
+
+		// abandoned uploads would otherwise be kept forever
+		go i.sweepUploadSessions()
+
 		// spin up a go routine acting as a listener for variant and
 		// gene ingest request updates, and variant and gene bulk indexing
 		go func() {
@@ -116,32 +155,77 @@ func (i *IngestionService) Init() {
 					i.IngestRequestMap[variantIngestionRequest.Id.String()] = variantIngestionRequest
 					i.IngestRequestMapMux.Unlock()
 
+					if err := esRepo.SaveVariantIngestionRequest(i.Config, i.ElasticsearchClient, variantIngestionRequest); err != nil {
+						fmt.Printf("Failed to persist variant ingestion request %s: %s\n", variantIngestionRequest.Id, err)
+					}
+
 				case geneIngestionRequest := <-i.GeneIngestRequestChan:
 					if geneIngestionRequest.State == ingest.Queued {
 						fmt.Printf("Queueing a new gene ingestion request for %s\n", geneIngestionRequest.Filename)
 					}
 
 					geneIngestionRequest.UpdatedAt = time.Now().String()
+					i.GeneIngestRequestMapMux.Lock()
 					i.GeneIngestRequestMap[geneIngestionRequest.Filename] = geneIngestionRequest
+					i.GeneIngestRequestMapMux.Unlock()
+
+					if err := esRepo.SaveGeneIngestionRequest(i.Config, i.ElasticsearchClient, geneIngestionRequest); err != nil {
+						fmt.Printf("Failed to persist gene ingestion request %s: %s\n", geneIngestionRequest.Filename, err)
+					}
 
 				case queuedVariantItem := <-i.IngestionBulkIndexingQueue:
 
 					queuedVariant := queuedVariantItem.Variant
 					wg := queuedVariantItem.WaitGroup
+					ctx := queuedVariantItem.Context
+					progress := queuedVariantItem.Progress
+
+					// drop variants belonging to a cancelled ingestion request
+					if ctx.Err() != nil {
+						wg.Done()
+						continue
+					}
 
 					// Prepare the data payload: encode article to JSON
-					variantData, marshallErr := json.Marshal(queuedVariant)
+					var (
+						variantData  []byte
+						marshallErr  error
+						variantIndex string
+						documentId   string // deterministic, such that re-ingested calls aren't duplicated
+					)
+					isQuarantined := queuedVariantItem.Quarantined != nil
+					if queuedCoverage := queuedVariantItem.Coverage; queuedCoverage != nil {
+						variantData, marshallErr = json.Marshal(queuedCoverage)
+						variantIndex = coverageIndexName(queuedCoverage.Chrom)
+						documentId = vcf.CoverageDocumentId(queuedCoverage)
+					} else if isQuarantined {
+						variantData, marshallErr = json.Marshal(queuedVariantItem.Quarantined)
+						variantIndex = esRepo.VariantQuarantineIndex
+					} else {
+						variantData, marshallErr = json.Marshal(queuedVariant)
+						variantIndex = variantIndexName(queuedVariant.Chrom)
+						documentId = vcf.DocumentId(queuedVariant)
+					}
+
+					action := string(wm.INDEX)
+					if queuedVariantItem.WriteMode == wm.CREATE && !isQuarantined {
+						action = string(wm.CREATE)
+					}
 					if marshallErr != nil {
-						log.Fatalf("Cannot encode variant %s: %s\n", queuedVariant.Id, marshallErr)
+						fmt.Printf("Cannot encode document for %s: %s\n", variantIndex, marshallErr)
+						atomic.AddInt64(&progress.DocumentsFailed, 1)
+						wg.Done()
+						continue
 					}
 
 					// Add an item to the BulkIndexer
 					marshallErr = i.IngestionBulkIndexer.Add(
-						context.Background(),
+						ctx,
 						esutil.BulkIndexerItem{
 							// Action field configures the operation to perform (index, create, delete, update)
-							Action: "index",
-							Index:  variantIndexName(queuedVariant.Chrom),
+							Action:     action,
+							Index:      variantIndex,
+							DocumentID: documentId,
 
 							// Body is an `io.Reader` with the payload
 							Body: bytes.NewReader(variantData),
@@ -149,14 +233,20 @@ func (i *IngestionService) Init() {
 							// OnSuccess is called for each successful operation
 							OnSuccess: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem) {
 								defer wg.Done()
-								//fmt.Printf("Successfully indexed: %s", item)
-								//atomic.AddUint64(&countSuccessful, 1)
+								if !isQuarantined {
+									atomic.AddInt64(&progress.DocumentsIndexed, 1)
+								}
 							},
 
 							// OnFailure is called for each failed operation
 							OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
 								defer wg.Done()
-								//atomic.AddUint64(&countFailed, 1)
+								if err == nil && res.Status == http.StatusConflict && action == string(wm.CREATE) {
+									// kept as is, as requested
+									atomic.AddInt64(&progress.DocumentsAlreadyIndexed, 1)
+									return
+								}
+								atomic.AddInt64(&progress.DocumentsFailed, 1)
 								if err != nil {
 									fmt.Printf("ERROR: %s\n", err)
 								} else {
@@ -167,6 +257,7 @@ func (i *IngestionService) Init() {
 					)
 					if marshallErr != nil {
 						fmt.Printf("Unexpected error: %s", marshallErr)
+						atomic.AddInt64(&progress.DocumentsFailed, 1)
 						wg.Done()
 					}
 
@@ -178,7 +269,9 @@ func (i *IngestionService) Init() {
 					// Prepare the data payload: encode article to JSON
 					geneData, marshallErr := json.Marshal(queuedGene)
 					if marshallErr != nil {
-						log.Fatalf("Cannot encode gene %+v: %s\n", queuedGene, marshallErr)
+						fmt.Printf("Cannot encode gene %+v: %s\n", queuedGene, marshallErr)
+						wg.Done()
+						continue
 					}
 
 					// Add an item to the BulkIndexer
@@ -222,148 +315,239 @@ func (i *IngestionService) Init() {
 	}
 }
 
-func (i *IngestionService) GenerateTabix(gzippedFilePath string) (string, string, error) {
-	cmd := exec.Command("tabix", "-f", gzippedFilePath)
-	cmdOutput := &bytes.Buffer{}
-	cmd.Stdout = cmdOutput
-	err := cmd.Run()
-	if err != nil {
-		fmt.Println(cmdOutput.String())
-		fmt.Println(err.Error())
-		os.Stderr.WriteString(err.Error())
-		return "", "", err
-	}
-	fmt.Print(cmdOutput.String())
+// GetAllVariantIngestionRequests returns the full history of variant ingestion
+// requests from the durable store, overlaid with the in-memory state of the
+// requests handled since startup (which is always the most up to date)
+func (i *IngestionService) GetAllVariantIngestionRequests() ([]*ingest.VariantIngestRequest, error) {
+	stored, err := esRepo.GetAllVariantIngestionRequests(i.Config, i.ElasticsearchClient)
 
-	dir, file := path.Split(fmt.Sprintf("%s.tbi", gzippedFilePath))
-	return dir, file, nil
+	i.IngestRequestMapMux.RLock()
+	defer i.IngestRequestMapMux.RUnlock()
+
+	requests := make([]*ingest.VariantIngestRequest, 0, len(stored)+len(i.IngestRequestMap))
+	for _, req := range stored {
+		if _, inMemory := i.IngestRequestMap[req.Id.String()]; !inMemory {
+			requests = append(requests, req)
+		}
+	}
+	for _, req := range i.IngestRequestMap {
+		requests = append(requests, req)
+	}
+	return requests, err
 }
 
-func (i *IngestionService) UploadVcfGzToDrs(cfg *models.Config, drsBridgeDirectory string, gzippedFileName string, drsUrl string, project_id, dataset_id string, authHeader string) string {
+// GetAllGeneIngestionRequests behaves like GetAllVariantIngestionRequests, for genes
+func (i *IngestionService) GetAllGeneIngestionRequests() ([]*ingest.GeneIngestRequest, error) {
+	stored, err := esRepo.GetAllGeneIngestionRequests(i.Config, i.ElasticsearchClient)
 
-	if cfg.Debug {
-		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	i.GeneIngestRequestMapMux.RLock()
+	defer i.GeneIngestRequestMapMux.RUnlock()
+
+	requests := make([]*ingest.GeneIngestRequest, 0, len(stored)+len(i.GeneIngestRequestMap))
+	for _, req := range stored {
+		if _, inMemory := i.GeneIngestRequestMap[req.Filename]; !inMemory {
+			requests = append(requests, req)
+		}
 	}
+	for _, req := range i.GeneIngestRequestMap {
+		requests = append(requests, req)
+	}
+	return requests, err
+}
 
-	path := fmt.Sprintf("%s/%s", drsBridgeDirectory, gzippedFileName)
+// NewVariantIngestionContext creates and keeps track of the
+// context governing the lifetime of a variant ingestion request
+func (i *IngestionService) NewVariantIngestionContext(requestId uuid.UUID) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
 
-	var (
-		drsId           string
-		drsResp         *http.Response
-		drsErr          error
-		attemptCount    int = 0
-		maxAttempts     int = 5
-		waitTimeSeconds int = 3
-	)
-	for {
-		// prepare upload request to drs
-		form := url.Values{}
-		form.Add("path", path)
-		form.Add("dataset_id", dataset_id)
-		form.Add("project_id", project_id)
-		form.Add("data_type", "variant")
+	i.IngestRequestMapMux.Lock()
+	i.IngestRequestCancellations[requestId.String()] = &ingestionCancellation{cancel: cancel}
+	i.IngestRequestMapMux.Unlock()
 
-		r, _ := http.NewRequest("POST", drsUrl+"/ingest", strings.NewReader(form.Encode()))
+	return ctx
+}
 
-		r.Header.Add("Authorization", authHeader)
-		r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+// ReleaseVariantIngestionContext frees the context of a variant
+// ingestion request once it is no longer running
+func (i *IngestionService) ReleaseVariantIngestionContext(requestId uuid.UUID) {
+	i.IngestRequestMapMux.Lock()
+	defer i.IngestRequestMapMux.Unlock()
 
-		client := &http.Client{}
+	if c, exists := i.IngestRequestCancellations[requestId.String()]; exists {
+		c.cancel()
+		delete(i.IngestRequestCancellations, requestId.String())
+	}
+}
+
+// ErrNotCancellable is returned when cancelling a request that already came to an end
+var ErrNotCancellable = errors.New("the request can no longer be cancelled")
 
-		// perform request
-		drsResp, drsErr = client.Do(r)
+// CancelVariantIngestion stops a queued or running variant ingestion request.
+// If rollback is requested, the documents already indexed for the request's
+// file are deleted once the ingestion has stopped
+func (i *IngestionService) CancelVariantIngestion(requestId uuid.UUID, rollback bool) (*ingest.VariantIngestRequest, error) {
+	i.IngestRequestMapMux.Lock()
+	defer i.IngestRequestMapMux.Unlock()
 
-		// check for errors, possibly try again
-		if drsErr != nil {
-			fmt.Printf("Upload to DRS error: %s\n", drsErr)
+	request, exists := i.IngestRequestMap[requestId.String()]
+	if !exists {
+		return nil, fmt.Errorf("variant ingestion request %s not found", requestId)
+	}
 
-			if attemptCount < maxAttempts {
-				// increment attempt counter
-				attemptCount++
+	c, cancellable := i.IngestRequestCancellations[requestId.String()]
+	if !cancellable {
+		return request, fmt.Errorf("variant ingestion request %s is %s: %w", requestId, request.State, ErrNotCancellable)
+	}
 
-				// give it a few seconds break
-				time.Sleep(time.Duration(waitTimeSeconds * int(time.Second)))
+	c.rollback = rollback
+	c.cancel()
 
-				fmt.Printf("trying again...\n")
-				continue
-			} else {
-				fmt.Printf("exiting upload loop...\n")
-				return "" // empty drs-id string
-			}
-		}
+	return request, nil
+}
 
-		// check for simple upload error (like db locked) and try again
-		fmt.Printf("Got a %d status code on DRS upload \n", drsResp.StatusCode)
-		if drsResp.StatusCode == 201 {
-			fmt.Printf("File %s upload to DRS succeeded: %d\n", gzippedFileName, drsResp.StatusCode)
+// GetVariantIngestionRequest returns a variant ingestion request handled since
+// startup or restored from the durable store, if there is such a request
+func (i *IngestionService) GetVariantIngestionRequest(requestId uuid.UUID) (*ingest.VariantIngestRequest, bool) {
+	i.IngestRequestMapMux.RLock()
+	defer i.IngestRequestMapMux.RUnlock()
 
-			// proceed with vcf processing
-			break
-		} else if drsResp.StatusCode == 401 {
-			// exit right away on 'unauthorized' status code
-			fmt.Printf("Received a '401 Unauthorized' from DRS -- exiting upload loop...\n")
-			return "" // empty drs-id string
-		} else {
-			// print response message
-			unsuccessfulAttemptResponseBody, unsuccessfulAttemptResponseErr := ioutil.ReadAll(drsResp.Body)
-			if unsuccessfulAttemptResponseErr != nil {
-				fmt.Printf("Error reading unsuccessful attempt response body: %v", unsuccessfulAttemptResponseErr)
-			} else {
-				fmt.Printf("Received from after failed attempt: %s\n", string(unsuccessfulAttemptResponseBody))
-			}
-			// continue the loop in the event this failure to parse response body is intermittent
+	request, exists := i.IngestRequestMap[requestId.String()]
+	return request, exists
+}
 
-			if attemptCount < maxAttempts {
-				// increment attempt counter
-				attemptCount++
+// IsVariantIngestionRollbackRequested reports whether the cancellation of
+// a variant ingestion request asked for its indexed documents to be deleted
+func (i *IngestionService) IsVariantIngestionRollbackRequested(requestId uuid.UUID) bool {
+	i.IngestRequestMapMux.RLock()
+	defer i.IngestRequestMapMux.RUnlock()
 
-				// give it a few seconds break
-				time.Sleep(time.Duration(waitTimeSeconds * int(time.Second)))
+	c, exists := i.IngestRequestCancellations[requestId.String()]
+	return exists && c.rollback
+}
 
-				fmt.Printf("Failed to upload to DRS after %d attempts.. Trying again...\n", attemptCount)
-				continue
-			} else {
-				fmt.Printf("After %d failed attempts, exiting upload loop...\n", attemptCount)
-				return "" // empty drs-id string
+func (i *IngestionService) restoreIngestionRequests() {
+	// retry until elasticsearch is reachable (gives up after the backoff's max elapsed time)
+	err := backoff.Retry(func() error {
+		return esRepo.MakeIngestionRequestIndices(i.Config, i.ElasticsearchClient)
+	}, backoff.NewExponentialBackOff())
+	if err != nil {
+		fmt.Printf("Unable to prepare the ingestion request store: %s\n", err)
+		return
+	}
+
+	variantRequests, err := esRepo.GetAllVariantIngestionRequests(i.Config, i.ElasticsearchClient)
+	if err != nil {
+		fmt.Printf("Unable to reload variant ingestion requests: %s\n", err)
+	}
+	for _, req := range variantRequests {
+		if (req.State == ingest.Queued || req.State == ingest.Downloading || req.State == ingest.Running) && i.createdBeforeStart(req.CreatedAt) {
+			// this request was cut short by the last shutdown
+			req.Message = fmt.Sprintf("Interrupted by an API restart while %s", strings.ToLower(string(req.State)))
+			req.State = ingest.Interrupted
+			req.UpdatedAt = time.Now().String()
+			if err := esRepo.SaveVariantIngestionRequest(i.Config, i.ElasticsearchClient, req); err != nil {
+				fmt.Printf("Failed to persist variant ingestion request %s: %s\n", req.Id, err)
 			}
 		}
-	}
 
-	responsebody, bodyerr := ioutil.ReadAll(drsResp.Body)
-	if bodyerr != nil {
-		fmt.Printf("Error reading body: %v\n", bodyerr)
-		return ""
+		i.IngestRequestMapMux.Lock()
+		if _, exists := i.IngestRequestMap[req.Id.String()]; !exists {
+			i.IngestRequestMap[req.Id.String()] = req
+		}
+		i.IngestRequestMapMux.Unlock()
 	}
 
-	jsonParsed, err := gabs.ParseJSON(responsebody)
+	geneRequests, err := esRepo.GetAllGeneIngestionRequests(i.Config, i.ElasticsearchClient)
 	if err != nil {
-		fmt.Printf("Parsing error: %s\n", err)
-		return ""
+		fmt.Printf("Unable to reload gene ingestion requests: %s\n", err)
 	}
-	drsId = jsonParsed.Path("id").Data().(string)
+	for _, req := range geneRequests {
+		if (req.State == ingest.Queued || req.State == ingest.Downloading || req.State == ingest.Running) && i.createdBeforeStart(req.CreatedAt) {
+			req.Message = fmt.Sprintf("Interrupted by an API restart while %s", strings.ToLower(string(req.State)))
+			req.State = ingest.Interrupted
+			req.UpdatedAt = time.Now().String()
+			if err := esRepo.SaveGeneIngestionRequest(i.Config, i.ElasticsearchClient, req); err != nil {
+				fmt.Printf("Failed to persist gene ingestion request %s: %s\n", req.Filename, err)
+			}
+		}
+
+		i.GeneIngestRequestMapMux.Lock()
+		if _, exists := i.GeneIngestRequestMap[req.Filename]; !exists {
+			i.GeneIngestRequestMap[req.Filename] = req
+		}
+		i.GeneIngestRequestMapMux.Unlock()
+	}
+
+	fmt.Printf("Restored %d variant and %d gene ingestion requests\n", len(variantRequests), len(geneRequests))
+}
 
-	fmt.Println("Get DRS ID: ", drsId)
+// createdBeforeStart reports whether a request was created by a previous run of the API,
+// given its creation time as formatted by time.Time.String() (requests whose creation
+// time can't be read are deemed to be that old)
+func (i *IngestionService) createdBeforeStart(createdAt string) bool {
+	// drop the monotonic clock reading, if any
+	createdAt, _, _ = strings.Cut(createdAt, " m=")
 
-	return drsId
+	created, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", createdAt)
+	return err != nil || created.Before(i.startedAt)
 }
 
-func (i *IngestionService) ProcessVcf(
+// GenerateTabix indexes a .vcf.gz (re-compressing it as BGZF first if need be),
+// and returns the directory and name of the resulting .tbi (or .csi)
+func (i *IngestionService) GenerateTabix(gzippedFilePath string) (string, string, error) {
+	indexPath, err := tabix.IndexVcf(gzippedFilePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	dir, file := path.Split(indexPath)
+	return dir, file, nil
+}
+
+// ProcessVcf reads a .vcf.gz and queues its calls for indexing (unless the dry-run option is set).
+// Malformed lines are reported to the validator and quarantined under the ingestion request's id
+// rather than indexed. Errors are returned for unreadable files, or when too many lines are malformed
+func (i *IngestionService) ProcessVcf(ctx context.Context, requestId uuid.UUID,
 	gzippedFilePath string, drsFileId string, dataset uuid.UUID,
-	assemblyId string, filterOutReferences bool,
-	lineProcessingConcurrencyLevel int) {
+	assemblyId string, options ingest.VariantIngestOptions,
+	lineProcessingConcurrencyLevel int, progress *ingest.VariantIngestProgress,
+	validator *vcf.Validator) (*vcf.Header, error) {
 
 	// ---   reopen gzipped file after having been copied to the temporary api-drs
 	//       bridge directory, as the stream depletes and needs a refresh
 	f, err := os.Open(gzippedFilePath)
 	if err != nil {
 		fmt.Println("Failed to open file - ", err)
-		return
+		return nil, err
 	}
 	defer f.Close()
 
-	gr, err := gzip.NewReader(f)
+	var totalBytes int64
+	if fileInfo, statErr := f.Stat(); statErr == nil {
+		totalBytes = fileInfo.Size()
+	}
+
+	return i.ProcessVcfStream(ctx, requestId, f, totalBytes, gzippedFilePath, drsFileId, dataset,
+		assemblyId, options, lineProcessingConcurrencyLevel, progress, validator)
+}
+
+// ProcessVcfStream is ProcessVcf for a .vcf.gz read from elsewhere than the local
+// file system (i.e. streamed from DRS). The name identifies it in logs and
+// quarantined lines; its total size is left at 0 when unknown
+func (i *IngestionService) ProcessVcfStream(ctx context.Context, requestId uuid.UUID,
+	source io.Reader, totalBytes int64, gzippedFilePath string, drsFileId string, dataset uuid.UUID,
+	assemblyId string, options ingest.VariantIngestOptions,
+	lineProcessingConcurrencyLevel int, progress *ingest.VariantIngestProgress,
+	validator *vcf.Validator) (*vcf.Header, error) {
+
+	// keep track of how much of the compressed file has been consumed
+	atomic.StoreInt64(&progress.StartedAt, time.Now().UnixNano())
+	atomic.StoreInt64(&progress.TotalBytes, totalBytes)
+	atomic.StoreInt64(&progress.BytesRead, 0)
+
+	gr, err := gzip.NewReader(&utils.CountingReader{Reader: source, Count: &progress.BytesRead})
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("%s is not a valid gzip file: %w", path.Base(gzippedFilePath), err)
 	}
 	defer gr.Close()
 
@@ -374,24 +558,58 @@ func (i *IngestionService) ProcessVcf(
 
 	var discoveredHeaders bool = false
 	var headers []string
+	vcfHeader := vcf.NewHeader() // only written to before the first row is processed
 	headerSampleIds := make(map[int]string)
 
 	skippedHomozygousReferencesCount := int32(0)
 
+	// REF alleles are checked against the reference genome of the assembly, when available
+	genome, genomeErr := i.ReferenceGenomes.Get(assemblyId)
+	if genomeErr != nil {
+		fmt.Printf("REF alleles of %s won't be validated: %s\n", gzippedFilePath, genomeErr)
+	} else {
+		validator.SetKnownContig(func(contig string) bool {
+			_, known := genome.Contig(strings.ReplaceAll(contig, "chr", ""))
+			return known
+		})
+	}
+
 	var _fileWG sync.WaitGroup
 
 	// "line ingestion queue"
 	// - manage # of lines being concurrently processed per file at any given time
 	lineProcessingQueue := make(chan bool, lineProcessingConcurrencyLevel)
 
+	var lineNumber int64
 	for scanner.Scan() {
+		lineNumber++
+
+		// stop reading as soon as the ingestion request is cancelled
+		if ctx.Err() != nil {
+			fmt.Printf("Ingestion of %s cancelled, stopped reading\n", gzippedFilePath)
+			break
+		}
+
+		// give up once too many lines were quarantined
+		if options.MaxErrors > 0 && atomic.LoadInt64(&progress.LinesQuarantined) > int64(options.MaxErrors) {
+			break
+		}
+
 		// Gather Header row by seeking the CHROM string
 		// Collect contigs (chromosomes) to create indices
 		line := scanner.Text()
+		atomic.AddInt64(&progress.LinesRead, 1)
 		if !discoveredHeaders {
-			if line[0:6] == "#CHROM" {
+			if strings.HasPrefix(line, "##") {
+				// keep track of the INFO and FORMAT field definitions
+				vcfHeader.AddMetaLine(line)
+				continue
+			}
+			if strings.HasPrefix(line, "#CHROM") {
 				// Split the string by tabs
 				headers = strings.Split(line, "\t")
+				vcfHeader.AddColumnsLine(line)
+				validator.SetHeader(vcfHeader)
 
 				for idx, header := range headers {
 					// determine if header is a default VCF header.
@@ -411,13 +629,28 @@ func (i *IngestionService) ProcessVcf(
 		// take a spot in the queue
 		lineProcessingQueue <- true
 		_fileWG.Add(1)
-		go func(line string, drsFileId string, fileWg *sync.WaitGroup) {
+		go func(line string, lineNumber int64, drsFileId string, fileWg *sync.WaitGroup) {
 			// free up a spot in the queue
 			defer func() { <-lineProcessingQueue }()
 
 			// ----  break up line
 			rowComponents := strings.Split(line, "\t")
 
+			// ----  rows not matching the '#CHROM' line can't be processed any further
+			if len(rowComponents) != len(headers) {
+				reason := fmt.Sprintf("expected %d columns, got %d", len(headers), len(rowComponents))
+				validator.LineError(lineNumber, reason)
+				i.quarantineLine(ctx, requestId, gzippedFilePath, lineNumber, line, []string{reason}, options, fileWg, progress)
+				return
+			}
+
+			// other problems are gathered as the row is processed
+			rowErrors := vcf.ValidateRow(rowComponents)
+			for _, reason := range rowErrors {
+				validator.LineError(lineNumber, reason)
+			}
+			validator.Record(rowComponents[0])
+
 			// ----  process more...
 			var tmpSamples []map[string]interface{}
 			tmpSamplesMutex := sync.RWMutex{}
@@ -429,6 +662,9 @@ func (i *IngestionService) ProcessVcf(
 			tmpVariant["assemblyId"] = assemblyId
 			tmpVariant["dataset"] = dataset.String()
 
+			// gVCF reference blocks are stored as coverage intervals rather than variants
+			isReferenceBlock := options.Gvcf && len(rowComponents) > 4 && vcf.IsReferenceBlock(strings.Split(rowComponents[4], ","))
+
 			// skip this call if need be
 			skipThisCall := false
 
@@ -453,8 +689,11 @@ func (i *IngestionService) ProcessVcf(
 							// If we haven't, create the index and add it to the contigs "set" (map).
 							contigMutex.Lock()
 							_, indexExists := contigs[value]
-							if !indexExists {
+							if !indexExists && !options.DryRun {
 								i.MakeVariantIndex(value)
+								if options.Gvcf {
+									i.MakeCoverageIndex(value)
+								}
 								contigs[value] = struct{}{} // add contig to the "set" of created configs
 							}
 							contigMutex.Unlock()
@@ -462,7 +701,18 @@ func (i *IngestionService) ProcessVcf(
 							tmpVariantMapMutex.Lock()
 							tmpVariant[key] = value
 							tmpVariantMapMutex.Unlock()
-						} else if key == "pos" || key == "qual" {
+						} else if key == "qual" {
+							// QUAL is a float as per the spec
+							if qual, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(qual) && !math.IsInf(qual, 0) {
+								tmpVariantMapMutex.Lock()
+								tmpVariant[key] = qual
+								tmpVariantMapMutex.Unlock()
+							} else {
+								tmpVariantMapMutex.Lock()
+								tmpVariant[key] = -1 // here to simulate a null value (i.e. a single period '.')
+								tmpVariantMapMutex.Unlock()
+							}
+						} else if key == "pos" {
 
 							// // Convert string's to int's, if possible
 							value, err := strconv.ParseInt(value, 10, 0)
@@ -496,27 +746,7 @@ func (i *IngestionService) ProcessVcf(
 							tmpVariant[key] = value
 							tmpVariantMapMutex.Unlock()
 						} else if key == "info" {
-							var allInfos []*indexes.Info
-
-							// Split all alleles by semi-colon
-							semiColonSeparations := strings.Split(value, ";")
-
-							for _, scSep := range semiColonSeparations {
-								// Split by equality symbol
-								equalitySeparations := strings.Split(scSep, "=")
-
-								if len(equalitySeparations) == 2 {
-									allInfos = append(allInfos, &indexes.Info{
-										Id:    equalitySeparations[0],
-										Value: equalitySeparations[1],
-									})
-								} else { // len(equalitySeparations) == 1
-									allInfos = append(allInfos, &indexes.Info{
-										Id:    "",
-										Value: equalitySeparations[0],
-									})
-								}
-							}
+							allInfos := vcf.ParseInfo(value, vcfHeader.Info)
 
 							tmpVariantMapMutex.Lock()
 							tmpVariant[key] = allInfos
@@ -534,14 +764,14 @@ func (i *IngestionService) ProcessVcf(
 						// support for multi-sampled calls
 						// assume first component of allValues is the genotype
 						genoTypeValue := allValues[0]
-						if filterOutReferences &&
-							(genoTypeValue == "0" || // haploid type references
-								genoTypeValue == "0|0" || genoTypeValue == "0/0") { // diploid type homezygous references
+						if options.FilterOutReferences && !isReferenceBlock &&
+							vcf.IsReferenceCall(genoTypeValue) { // haploid type references, and homozygous references of any ploidy
 							// skip adding this sample to the 'tmpSamples' list which
 							// then goes to be further processed into a variant document
 
 							// increase count of skipped calls
 							atomic.AddInt32(&skippedHomozygousReferencesCount, 1)
+							atomic.AddInt64(&progress.SkippedHomozygousReferences, 1)
 
 							return
 						}
@@ -564,6 +794,24 @@ func (i *IngestionService) ProcessVcf(
 				return
 			}
 
+			// --- validate the REF allele, and maybe left-align indels
+			if genome != nil {
+				i.checkReferenceAlleles(genome, tmpVariant, options, progress)
+			}
+
+			// --- determine the span of the variant (structural variants
+			//     spanning beyond their position, see 'vcf.VariantExtent')
+			if pos, isInt := tmpVariant["pos"].(int64); isInt {
+				infos, _ := tmpVariant["info"].([]*indexes.Info)
+				ref, _ := tmpVariant["ref"].([]string)
+				alt, _ := tmpVariant["alt"].([]string)
+
+				extent := vcf.VariantExtent(int(pos), ref, alt, infos)
+				tmpVariant["end"] = extent.End
+				tmpVariant["svType"] = extent.SvType
+				tmpVariant["svLen"] = extent.SvLen
+			}
+
 			// --- prep formats + samples
 			var samples []*indexes.Sample
 
@@ -584,8 +832,10 @@ func (i *IngestionService) ProcessVcf(
 				fmt.Printf("Something went wrong, but was caught:\ntmpVariant is nil for file with DRS fileId `%s` at line `%s`  \n\n", drsFileId, line)
 				return
 			}
+			var formats []string
 			if utils.KeyExists(tmpVariant, "format") {
-				for i, f := range tmpVariant["format"].([]string) {
+				formats = tmpVariant["format"].([]string)
+				for i, f := range formats {
 					// ----- check formats
 					switch f {
 					case "GT":
@@ -604,179 +854,50 @@ func (i *IngestionService) ProcessVcf(
 			}
 			// --
 
+			var coverages []*indexes.Coverage
+
 			for _, ts := range tmpSamples {
 				sample := &indexes.Sample{}
 				variation := &indexes.Variation{}
+				isReferenceCall := false
 
 				tmpKeyString := ts["key"].(string)
 				tmpValueStrings := ts["values"].([]string)
 				for k := range tmpValueStrings {
 					if hasGenotype && k == genotypePosition {
-						// create genotype from value
-						gtString := tmpValueStrings[k]
-
-						var ploidy constants.Ploidy
-						// as defined by
-						// https://samtools.github.io/hts-specs/VCFv4.1.pdf , page 25
-						//		"...
-						//		 0 		as a haploid it is represented by a single byte 	0x02
-						//		 1 		as a haploid it is represented by a single byte 	0x04
-						//		..."
-
-						// determine ploidy
-						if !strings.Contains(gtString, "|") && !strings.Contains(gtString, "/") {
-							ploidy = p.Haploid
-						} else {
-							// assume number of "|" or "/" present is 1
-							ploidy = p.Diploid
-						}
-						// TODO: handle triploid?
-
-						var (
-							zyg                constants.Zygosity
-							phased             bool
-							alleleStringSplits []string
-							alleleLeft         int = -1
-							alleleRight        int = -1
-							errLeft            error
-							errRight           error
-						)
-
-						switch ploidy {
-						case p.Haploid:
-							// handle haploid
-
-							// -- allele
-							//		piggy-back off of diploid implementation and use
-							//		alleleLeft to store the haploid single allele
-							if gtString == "." {
-								alleleLeft = 0
-							} else {
-								// -- if error, probably an unknown character -- assign -1
-								alleleLeft, errLeft = strconv.Atoi(gtString)
-								if errLeft != nil {
-									alleleLeft = -1
-								}
-							}
-
-							// -- zygosity:
-							if alleleLeft == -1 {
-								zyg = z.Unknown
-							} else {
-								switch alleleLeft {
-								default:
-									zyg = z.Alternate
-									// covers 1 and greater
-
-								case 0:
-									zyg = z.Reference
-								}
-							}
-
-						case p.Diploid:
-							// handle diploid
-
-							// -- phase
-							phased := strings.Contains(gtString, "|")
-
-							if phased {
-								alleleStringSplits = strings.Split(gtString, "|")
-							} else {
-								alleleStringSplits = strings.Split(gtString, "/")
-							}
-
-							// -- alleles
-							switch len(alleleStringSplits) {
-							case 1:
-								if alleleStringSplits[0] == "." {
-									alleleLeft = 0
-								} else {
-									// -- if error, probably an unknown character -- assign -1
-									alleleLeft, errLeft = strconv.Atoi(alleleStringSplits[0])
-									if errLeft != nil {
-										alleleLeft = -1
-									}
-								}
-							case 2:
-								// convert string to int
-								// - check and handle when 'gtString' contains '.'s
-								if alleleStringSplits[0] == "." && alleleStringSplits[1] == "." {
-									alleleLeft = 0
-									alleleRight = 0
-								} else {
-									// -- if error, probably an unknown character -- assign -1
-									alleleLeft, errLeft = strconv.Atoi(alleleStringSplits[0])
-									if errLeft != nil {
-										alleleLeft = -1
-									}
-
-									alleleRight, errRight = strconv.Atoi(alleleStringSplits[1])
-									if errRight != nil {
-										alleleRight = -1
-									}
-								}
-								// default (0) : let default -1 and -1 be handled
-							}
-
-							// -- zygosity:
-							if alleleLeft == -1 || alleleRight == -1 {
-								zyg = z.Unknown
-							} else {
-								switch alleleLeft == alleleRight {
-								case true:
-									switch alleleLeft * alleleRight {
-									case 0:
-										zyg = z.HomozygousReference
-									default:
-										zyg = z.HomozygousAlternate
-									}
-								case false:
-									zyg = z.Heterozygous
-								}
-							}
-
-							// TODO: handle triploid?
-
-							// default:
-							// 	// error ?
+						// create genotype from value, of any ploidy
+						genotype := vcf.ParseGenotype(tmpValueStrings[k])
+						isReferenceCall = vcf.IsReferenceCall(tmpValueStrings[k])
+						if gtErr := vcf.ValidateGenotype(tmpValueStrings[k], len(tmpVariant["alt"].([]string))); gtErr != nil {
+							validator.MalformedGenotype(lineNumber, tmpKeyString, gtErr)
+							rowErrors = append(rowErrors, fmt.Sprintf("sample %s: %s", tmpKeyString, gtErr))
 						}
 
 						//   By this point, tmpVariant["alt"] is populated with
 						//   an array of strings, i.e ["C", "CTT", "CTTTT", ...] .
-						//   Using the values in 'alleleLeft' and 'alleleRight' as
-						//   reference to which alleles are "most-likely", format and
-						//   store alleles specific to each sample
+						//   Using the genotype's allele indexes as reference to
+						//   which alleles are "most-likely", format and store
+						//   alleles specific to each sample
 
 						// indexing ref/alt in a vcf row:
 						//
 						//       0       1, 2, 3, ...
 						// ...  REF		ALT			...
 						// ...  G		CT,CTT,CTTT
+						allAlleles := vcf.GenotypeAlleles(genotype.AlleleIndexes, tmpVariant["ref"].([]string), tmpVariant["alt"].([]string))
 
+						// the left/right pair holds the first two alleles (the right
+						// one being left empty for haploid calls)
 						var alleles indexes.AllelePair
-						// hold a temporary pointer to the current state of this-variant's 'alt' and 'ref' for brevity
-						tmpVariantAlt := tmpVariant["alt"].([]string)
-						tmpVariantRef := tmpVariant["ref"].([]string)
-
-						if alleleLeft > 0 {
-							alleles.Left = tmpVariantAlt[alleleLeft-1]
-						} else {
-							alleles.Left = tmpVariantRef[0]
+						alleles.Left = allAlleles[0]
+						if len(allAlleles) > 1 {
+							alleles.Right = allAlleles[1]
 						}
 
-						if ploidy == p.Diploid {
-							if alleleRight > 0 {
-								alleles.Right = tmpVariantAlt[alleleRight-1]
-							} else {
-								alleles.Right = tmpVariantRef[0]
-							}
-						}
-
-						variation.Genotype = indexes.Genotype{
-							Phased:   phased,
-							Zygosity: zyg,
-						}
+						variation.Genotype = genotype
 						variation.Alleles = alleles
+						variation.AllAlleles = allAlleles
+						variation.AlleleOccurrences = utils.NumberOccurrences(allAlleles)
 
 					} else if hasGenotypeProbability && k == genotypeProbabilityPosition {
 						// create genotype probability from value
@@ -799,7 +920,32 @@ func (i *IngestionService) ProcessVcf(
 							}
 						}
 
+					} else if k < len(formats) {
+						// any other FORMAT field (DP, GQ, AD, etc.)
+						vcf.ParseFormatField(variation, formats[k], tmpValueStrings[k], vcfHeader.Format)
+					}
+				}
+
+				if isReferenceBlock {
+					// only confident hom-ref calls count as coverage (not no-calls such as './.')
+					start, hasStart := tmpVariant["pos"].(int64)
+					end, hasEnd := tmpVariant["end"].(int)
+					if isReferenceCall && hasStart && hasEnd {
+						coverages = append(coverages, &indexes.Coverage{
+							Chrom:           tmpVariant["chrom"].(string),
+							Start:           int(start),
+							End:             end,
+							SampleId:        tmpKeyString,
+							GenotypeQuality: variation.GenotypeQuality,
+							MinReadDepth:    variation.MinReadDepth,
+							ReadDepth:       variation.ReadDepth,
+							FileId:          drsFileId,
+							Dataset:         dataset.String(),
+							AssemblyId:      assemblyId,
+							CreatedTime:     time.Now(),
+						})
 					}
+					continue
 				}
 
 				sample.Id = tmpKeyString
@@ -808,30 +954,94 @@ func (i *IngestionService) ProcessVcf(
 				samples = append(samples, sample)
 			}
 
+			if len(rowErrors) > 0 {
+				i.quarantineLine(ctx, requestId, gzippedFilePath, lineNumber, line, rowErrors, options, fileWg, progress)
+				return
+			}
+
+			if options.DryRun {
+				// the row was read for validation purposes only
+				fileWg.Done()
+				return
+			}
+
+			if len(coverages) > 0 {
+				fileWg.Add(len(coverages))
+				for _, coverage := range coverages {
+					atomic.AddInt64(&progress.DocumentsQueued, 1)
+					atomic.AddInt64(&progress.ReferenceBlocks, 1)
+					i.IngestionBulkIndexingQueue <- &structs.IngestionQueueStructure{
+						Context:   ctx,
+						Coverage:  coverage,
+						WaitGroup: fileWg,
+						Progress:  progress,
+						WriteMode: options.WriteMode,
+					}
+				}
+			}
+
+			// Create a whole variant document for each sample found on this VCF line
+			// TODO: revisit this model as it is surely not storage efficient
+			var variants []*indexes.Variant
+			for _, sample := range samples {
+				tmpVariant["sample"] = sample
+				var resultingVariant indexes.Variant
+				mapstructure.Decode(tmpVariant, &resultingVariant)
+
+				resultingVariant.CreatedTime = time.Now()
+
+				if !options.Decompose && !options.Normalize {
+					variants = append(variants, &resultingVariant)
+					continue
+				}
+
+				// ---- split multi-allelic records, and/or trim the bases shared by their alleles
+				decomposed := []indexes.Variant{resultingVariant}
+				if options.Decompose {
+					decomposed = vcf.Decompose(resultingVariant)
+				}
+				for idx := range decomposed {
+					variant := &decomposed[idx]
+					if options.Normalize {
+						vcf.Normalize(variant)
+					}
+
+					// i.e. the '0/1' call of a '0/2' sample, after decomposition
+					zyg := variant.Sample.Variation.Genotype.Zygosity
+					if options.FilterOutReferences && len(decomposed) > 1 &&
+						(zyg == z.Reference || zyg == z.HomozygousReference) {
+						atomic.AddInt32(&skippedHomozygousReferencesCount, 1)
+						atomic.AddInt64(&progress.SkippedHomozygousReferences, 1)
+						continue
+					}
+					variants = append(variants, variant)
+				}
+			}
+
 			// Determine if this variant is worth ingesting (if it has
 			// any samples after having maybe filtered out all homozygous
 			// references, and thus maybe all samples from the call
 			// [i.e. if this is a single-sample VCF])
-			if len(samples) > 0 {
+			if len(variants) > 0 {
 
 				// for multi-sample vcfs, add 1 to the waitgroup for
-				// each sample (minus 1 given the initial addition)
-				fileWg.Add(len(samples) - 1)
+				// each document (minus 1 given the initial addition)
+				fileWg.Add(len(variants) - 1)
 
-				// Create a whole variant document for each sample found on this VCF line
-				// TODO: revisit this model as it is surely not storage efficient
-				for _, sample := range samples {
-					tmpVariant["sample"] = sample
-					// ---	 push to a bulk "queue"
-					var resultingVariant indexes.Variant
-					mapstructure.Decode(tmpVariant, &resultingVariant)
-
-					resultingVariant.CreatedTime = time.Now()
+				for _, resultingVariant := range variants {
+					// once decomposed, per-allele values are those of the remaining allele
+					resultingVariant.InfoTyped = vcf.NumericValuesById(resultingVariant.Info)
+					resultingVariant.Sample.Variation.FormatsTyped = vcf.NumericValuesById(resultingVariant.Sample.Variation.Formats)
 
+					// ---	 push to a bulk "queue"
 					// pass variant (along with a waitgroup) to the channel
+					atomic.AddInt64(&progress.DocumentsQueued, 1)
 					i.IngestionBulkIndexingQueue <- &structs.IngestionQueueStructure{
-						Variant:   &resultingVariant,
+						Context:   ctx,
+						Variant:   resultingVariant,
 						WaitGroup: fileWg,
+						Progress:  progress,
+						WriteMode: options.WriteMode,
 					}
 				}
 			} else {
@@ -839,7 +1049,7 @@ func (i *IngestionService) ProcessVcf(
 				defer fileWg.Done()
 				return
 			}
-		}(line, drsFileId, &_fileWG)
+		}(line, lineNumber, drsFileId, &_fileWG)
 	}
 
 	// allowing all lines to be queued up and waited for
@@ -851,6 +1061,85 @@ func (i *IngestionService) ProcessVcf(
 	_fileWG.Wait()
 
 	fmt.Printf("File %s waited for and complete!\n\t- Number of skipped Reference and/or Homozygous-Reference calls: %d\n", gzippedFilePath, skippedHomozygousReferencesCount)
+
+	if quarantined := atomic.LoadInt64(&progress.LinesQuarantined); options.MaxErrors > 0 && quarantined > int64(options.MaxErrors) {
+		return vcfHeader, fmt.Errorf("stopped reading %s after %d malformed lines, beyond the limit of %d", path.Base(gzippedFilePath), quarantined, options.MaxErrors)
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return vcfHeader, fmt.Errorf("failed to read %s after line %d: %w", path.Base(gzippedFilePath), lineNumber, scanErr)
+	}
+	if !discoveredHeaders {
+		return vcfHeader, fmt.Errorf("no '#CHROM' header line found in %s", path.Base(gzippedFilePath))
+	}
+	return vcfHeader, nil
+}
+
+// quarantineLine keeps a malformed line as is, along with the reasons why, under the id of its
+// ingestion request. Lines are only counted during dry runs
+func (i *IngestionService) quarantineLine(ctx context.Context, requestId uuid.UUID, gzippedFilePath string,
+	lineNumber int64, line string, reasons []string,
+	options ingest.VariantIngestOptions, fileWg *sync.WaitGroup, progress *ingest.VariantIngestProgress) {
+
+	atomic.AddInt64(&progress.LinesQuarantined, 1)
+	if options.DryRun || requestId == uuid.Nil {
+		fileWg.Done()
+		return
+	}
+
+	i.IngestionBulkIndexingQueue <- &structs.IngestionQueueStructure{
+		Context: ctx,
+		Quarantined: &indexes.QuarantinedLine{
+			RequestId:   requestId.String(),
+			Filename:    path.Base(gzippedFilePath),
+			Line:        lineNumber,
+			Raw:         line,
+			Reason:      strings.Join(reasons, "; "),
+			CreatedTime: time.Now(),
+		},
+		WaitGroup: fileWg,
+		Progress:  progress,
+	}
+}
+
+// checkReferenceAlleles compares the REF allele of a row with the reference genome, keeping
+// count of mismatches. Matching indels are then left-aligned if requested
+func (i *IngestionService) checkReferenceAlleles(genome *reference.Fasta, tmpVariant map[string]interface{}, options ingest.VariantIngestOptions, progress *ingest.VariantIngestProgress) {
+	chrom, _ := tmpVariant["chrom"].(string)
+	pos, hasPos := tmpVariant["pos"].(int64)
+	ref, _ := tmpVariant["ref"].([]string)
+	alt, _ := tmpVariant["alt"].([]string)
+	if !hasPos || len(ref) == 0 || ref[0] == "" || strings.ContainsAny(ref[0], "<>") {
+		return
+	}
+
+	contig, known := genome.Contig(chrom)
+	if !known {
+		return
+	}
+
+	atomic.AddInt64(&progress.RefChecked, 1)
+	bases, fetchErr := genome.Fetch(contig, int(pos), int(pos)+len(ref[0])-1)
+	if fetchErr != nil || !vcf.RefMatches(ref[0], bases) {
+		atomic.AddInt64(&progress.RefMismatches, 1)
+		return
+	}
+
+	if options.LeftAlign {
+		alignedPos, alignedRef, alignedAlt := vcf.LeftAlign(int(pos), ref[0], alt, func(p int) (byte, bool) {
+			base, err := genome.Fetch(contig, p, p)
+			if err != nil {
+				return 0, false
+			}
+			return base[0], true
+		})
+		if alignedPos != int(pos) {
+			atomic.AddInt64(&progress.LeftAligned, 1)
+		}
+
+		tmpVariant["pos"] = int64(alignedPos)
+		tmpVariant["ref"] = []string{alignedRef}
+		tmpVariant["alt"] = alignedAlt
+	}
 }
 
 func (i *IngestionService) FilenameAlreadyRunning(filename string) bool {
@@ -866,27 +1155,104 @@ func (i *IngestionService) FilenameAlreadyRunning(filename string) bool {
 }
 
 func (i *IngestionService) MakeVariantIndex(c string) {
+	i.makeContigIndex(c, variantIndexName(c), indexes.VARIANT_INDEX_MAPPING)
+}
+
+// MakeCoverageIndex creates the index holding the gVCF reference blocks of a contig
+func (i *IngestionService) MakeCoverageIndex(c string) {
+	i.makeContigIndex(c, coverageIndexName(c), indexes.COVERAGE_INDEX_MAPPING)
+}
+
+func (i *IngestionService) makeContigIndex(c string, contigIndex string, mapping map[string]interface{}) {
 	var client = i.ElasticsearchClient
-	var contigIndex = variantIndexName(c)
 
 	res, err := client.Indices.Exists([]string{contigIndex})
 	if res.StatusCode == 404 {
-		mappings, _ := json.Marshal(indexes.VARIANT_INDEX_MAPPING)
+		mappings, _ := json.Marshal(mapping)
 		res, _ := client.Indices.Create(
 			contigIndex,
 			client.Indices.Create.WithBody(strings.NewReader(fmt.Sprintf(`{"mappings": %s}`, mappings))),
 		)
 
-		fmt.Printf("Creating contig index %s - got response: %s\n", c, res.String())
+		fmt.Printf("Creating contig index %s - got response: %s\n", contigIndex, res.String())
 	} else if err != nil {
 		// The actual check didn't work properly (e.g., couldn't contact ES).
-		fmt.Printf("Contig index %s existence-check got error: %s\n", c, err)
+		fmt.Printf("Contig index %s existence-check got error: %s\n", contigIndex, err)
 	} else {
 		// The check worked and the index already exists, so we shouldn't try to recreate it.
-		fmt.Printf("Contig index %s already exists; skipping creation\n", c)
+		// Fields added to the mapping since it was created are mapped nonetheless, such that
+		// they're queried as expected once documents holding them are (re-)ingested. Fields
+		// already mapped (i.e. a 'qual' mapped as a long by older versions) are left as they are
+		i.addUnmappedFields(contigIndex, mapping)
+	}
+}
+
+func (i *IngestionService) addUnmappedFields(index string, mapping map[string]interface{}) {
+	var client = i.ElasticsearchClient
+
+	res, err := client.Indices.GetMapping(client.Indices.GetMapping.WithIndex(index))
+	if err != nil {
+		fmt.Printf("Contig index %s already exists; failed to get its mapping: %s\n", index, err)
+		return
+	}
+	var existing map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	decodeErr := json.NewDecoder(res.Body).Decode(&existing)
+	res.Body.Close()
+	if decodeErr != nil || res.IsError() {
+		fmt.Printf("Contig index %s already exists; failed to get its mapping: %s\n", index, res.Status())
+		return
+	}
+
+	mappings, _ := json.Marshal(unmappedFields(mapping, existing[index].Mappings))
+	res, err = client.Indices.PutMapping(
+		strings.NewReader(string(mappings)),
+		client.Indices.PutMapping.WithIndex(index),
+	)
+	if err != nil {
+		fmt.Printf("Contig index %s already exists; failed to update its mapping: %s\n", index, err)
+		return
 	}
+	defer res.Body.Close()
+	fmt.Printf("Contig index %s already exists; updating its mapping - got response: %s\n", index, res.String())
+}
+
+// unmappedFields narrows a mapping down to the fields (and dynamic templates) an existing mapping lacks
+func unmappedFields(mapping map[string]interface{}, existing map[string]interface{}) map[string]interface{} {
+	narrowed := map[string]interface{}{}
+	for key, value := range mapping {
+		if key != "properties" {
+			narrowed[key] = value
+		}
+	}
+
+	properties, _ := mapping["properties"].(map[string]interface{})
+	existingProperties, _ := existing["properties"].(map[string]interface{})
+	newProperties := map[string]interface{}{}
+	for name, field := range properties {
+		existingField, mapped := existingProperties[name].(map[string]interface{})
+		if !mapped {
+			newProperties[name] = field
+			continue
+		}
+
+		// objects may be missing some of their own fields
+		if object, isObject := field.(map[string]interface{}); isObject && object["properties"] != nil && existingField["properties"] != nil {
+			narrowedObject := unmappedFields(object, existingField)
+			if len(narrowedObject["properties"].(map[string]interface{})) > 0 {
+				newProperties[name] = narrowedObject
+			}
+		}
+	}
+	narrowed["properties"] = newProperties
+	return narrowed
 }
 
 func variantIndexName(contig string) string {
 	return fmt.Sprintf("variants-%s", strings.ToLower(contig))
 }
+
+func coverageIndexName(contig string) string {
+	return fmt.Sprintf("coverage-%s", strings.ToLower(contig))
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"gohan/api/models/ingest"
+	esRepo "gohan/api/repositories/elasticsearch"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// idempotency keys are forgotten after this long, such that
+// the same files can deliberately be ingested again later on
+const IdempotencyKeyRetention = 24 * time.Hour
+
+// idempotencyKeyLock serializes the submissions of a given idempotency key
+type idempotencyKeyLock struct {
+	sync.Mutex
+	holders int // the lock is forgotten once no submission holds or awaits it
+}
+
+// SubmitVariantIngestJob creates a new variant ingestion job, whose requests are queued by 'queue',
+// unless a job was already submitted with the same idempotency key, in which case that original
+// job is returned instead (along with 'true'). Submissions sharing an idempotency key are
+// serialized, such that concurrent retries of the same job can't both go through, while
+// others (along with their possibly slow 'queue') go through concurrently
+func (i *IngestionService) SubmitVariantIngestJob(idempotencyKey string, request ingest.VariantIngestJobRequestDTO, queue func() ([]ingest.IngestResponseDTO, error)) (*ingest.VariantIngestJob, bool, error) {
+	if idempotencyKey != "" {
+		unlock := i.lockIdempotencyKey(idempotencyKey)
+		defer unlock()
+
+		existing, err := i.getVariantIngestJob(idempotencyKey)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil && time.Since(existing.CreatedAt) < IdempotencyKeyRetention && !i.variantIngestJobFailed(existing) {
+			return existing, true, nil
+		}
+	}
+
+	job := &ingest.VariantIngestJob{
+		Id:             uuid.New(),
+		IdempotencyKey: idempotencyKey,
+		Request:        request,
+		CreatedAt:      time.Now(),
+	}
+	requests, err := queue()
+	if err != nil {
+		return nil, false, err
+	}
+	job.Requests = requests
+
+	if idempotencyKey != "" {
+		i.variantIngestJobsMux.Lock()
+		i.variantIngestJobs[idempotencyKey] = job
+		i.variantIngestJobsMux.Unlock()
+
+		if err := esRepo.SaveVariantIngestJob(i.Config, i.ElasticsearchClient, job); err != nil {
+			// the job is still remembered until the next restart
+			fmt.Printf("Failed to persist variant ingestion job %s: %s\n", job.Id, err)
+		}
+	}
+
+	return job, false, nil
+}
+
+// -- internal use only --
+func (i *IngestionService) lockIdempotencyKey(idempotencyKey string) func() {
+	i.variantIngestJobsMux.Lock()
+	l, exists := i.variantIngestJobLocks[idempotencyKey]
+	if !exists {
+		l = &idempotencyKeyLock{}
+		i.variantIngestJobLocks[idempotencyKey] = l
+	}
+	l.holders++
+	i.variantIngestJobsMux.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		i.variantIngestJobsMux.Lock()
+		l.holders--
+		if l.holders == 0 {
+			delete(i.variantIngestJobLocks, idempotencyKey)
+		}
+		i.variantIngestJobsMux.Unlock()
+	}
+}
+
+// variantIngestJobFailed tells whether any request of a job ended without being ingested,
+// in which case resubmitting it starts a new job, rather than returning the failed one
+func (i *IngestionService) variantIngestJobFailed(job *ingest.VariantIngestJob) bool {
+	i.IngestRequestMapMux.RLock()
+	defer i.IngestRequestMapMux.RUnlock()
+
+	for _, r := range job.Requests {
+		if request, exists := i.IngestRequestMap[r.Id.String()]; exists {
+			switch request.State {
+			case ingest.Error, ingest.Cancelled, ingest.Interrupted:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getVariantIngestJob must be called with the idempotency key locked
+func (i *IngestionService) getVariantIngestJob(idempotencyKey string) (*ingest.VariantIngestJob, error) {
+	i.variantIngestJobsMux.Lock()
+	job, exists := i.variantIngestJobs[idempotencyKey]
+	i.variantIngestJobsMux.Unlock()
+	if exists {
+		return job, nil
+	}
+
+	// may have been submitted before the last restart
+	job, err := esRepo.GetVariantIngestJob(i.Config, i.ElasticsearchClient, idempotencyKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up idempotency key %s: %s", idempotencyKey, err)
+	}
+	if job != nil {
+		i.variantIngestJobsMux.Lock()
+		i.variantIngestJobs[idempotencyKey] = job
+		i.variantIngestJobsMux.Unlock()
+	}
+	return job, nil
+}
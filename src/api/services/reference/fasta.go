@@ -0,0 +1,131 @@
+package reference
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Fasta gives random access to the sequences of a FASTA file indexed with
+// 'samtools faidx', whose index is expected next to it (<path>.fai)
+type Fasta struct {
+	file  *os.File
+	index map[string]faiEntry
+}
+
+// faiEntry is a line of a .fai index, describing the layout of a sequence in the FASTA
+type faiEntry struct {
+	length    int64 // number of bases
+	offset    int64 // of the first base, in bytes
+	lineBases int64
+	lineWidth int64 // in bytes, including the line terminator
+}
+
+func OpenFasta(path string) (*Fasta, error) {
+	indexFile, err := os.Open(path + ".fai")
+	if err != nil {
+		return nil, err
+	}
+	defer indexFile.Close()
+
+	index, err := parseFai(indexFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index %s.fai: %w", path, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Fasta{file: file, index: index}, nil
+}
+
+func (f *Fasta) Close() error {
+	return f.file.Close()
+}
+
+// Contig finds the name under which a contig is stored in the FASTA, given that
+// contigs are stored by gohan without their 'chr' prefix (i.e. '1' -> 'chr1', 'M' -> 'chrM' or 'MT')
+func (f *Fasta) Contig(name string) (string, bool) {
+	candidates := []string{name, "chr" + name}
+	switch strings.ToUpper(name) {
+	case "M", "MT":
+		candidates = append(candidates, "MT", "chrM", "chrMT")
+	}
+
+	for _, candidate := range candidates {
+		if _, exists := f.index[candidate]; exists {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// Fetch returns the (uppercase) bases of a contig from start to end, 1-based and inclusive
+func (f *Fasta) Fetch(contig string, start int, end int) (string, error) {
+	entry, exists := f.index[contig]
+	if !exists {
+		return "", fmt.Errorf("unknown contig %s", contig)
+	}
+	if start < 1 || end < start || int64(end) > entry.length {
+		return "", fmt.Errorf("%s:%d-%d is out of the bounds of %s (1-%d)", contig, start, end, contig, entry.length)
+	}
+
+	from := entry.byteOffset(int64(start - 1))
+	to := entry.byteOffset(int64(end-1)) + 1
+
+	buf := make([]byte, to-from)
+	if _, err := f.file.ReadAt(buf, from); err != nil && err != io.EOF {
+		return "", err
+	}
+
+	// sequences span multiple lines
+	buf = bytes.ReplaceAll(buf, []byte("\n"), nil)
+	buf = bytes.ReplaceAll(buf, []byte("\r"), nil)
+	return strings.ToUpper(string(buf)), nil
+}
+
+func (e faiEntry) byteOffset(position int64) int64 {
+	return e.offset + (position/e.lineBases)*e.lineWidth + position%e.lineBases
+}
+
+func parseFai(r io.Reader) (map[string]faiEntry, error) {
+	index := map[string]faiEntry{}
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		columns := strings.Split(line, "\t")
+		if len(columns) < 5 {
+			return nil, fmt.Errorf("line %d: expected 5 columns, got %d", lineNumber, len(columns))
+		}
+
+		var values [4]int64
+		for i := range values {
+			value, err := strconv.ParseInt(columns[i+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			values[i] = value
+		}
+		if values[2] <= 0 || values[3] < values[2] {
+			return nil, fmt.Errorf("line %d: invalid line lengths", lineNumber)
+		}
+
+		index[columns[0]] = faiEntry{
+			length:    values[0],
+			offset:    values[1],
+			lineBases: values[2],
+			lineWidth: values[3],
+		}
+	}
+	return index, scanner.Err()
+}
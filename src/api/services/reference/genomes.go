@@ -0,0 +1,59 @@
+package reference
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+)
+
+var ErrNoReferenceGenome = errors.New("no reference genome")
+
+// Extensions under which the FASTA of an assembly is looked for, in order
+var fastaExtensions = []string{".fa", ".fasta", ".fna"}
+
+// Genomes opens the reference genome of each assembly on first use, found in
+// the configured directory as <assemblyId>.fa (or .fasta, .fna) along with its .fai index
+type Genomes struct {
+	directory string
+	opened    map[string]*Fasta
+	mux       sync.Mutex
+}
+
+func NewGenomes(directory string) *Genomes {
+	return &Genomes{
+		directory: directory,
+		opened:    map[string]*Fasta{},
+	}
+}
+
+// Get returns the reference genome of an assembly, or ErrNoReferenceGenome if there is none.
+// Missing genomes are looked for again on each call, such that they can be added at any time
+func (g *Genomes) Get(assemblyId string) (*Fasta, error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	if fasta, exists := g.opened[assemblyId]; exists {
+		return fasta, nil
+	}
+	if g.directory == "" || assemblyId == "" || path.Base(assemblyId) != assemblyId {
+		return nil, ErrNoReferenceGenome
+	}
+
+	for _, extension := range fastaExtensions {
+		fastaPath := path.Join(g.directory, assemblyId+extension)
+		if _, err := os.Stat(fastaPath); err != nil {
+			continue
+		}
+
+		fasta, err := OpenFasta(fastaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the reference genome of %s: %w", assemblyId, err)
+		}
+		g.opened[assemblyId] = fasta
+		return fasta, nil
+	}
+
+	return nil, ErrNoReferenceGenome
+}
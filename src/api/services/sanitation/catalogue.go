@@ -0,0 +1,41 @@
+package sanitation
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// DatasetCatalogue lists the datasets known to the metadata service,
+// which every variant is expected to belong to
+type DatasetCatalogue interface {
+	DatasetIds() ([]string, error)
+}
+
+// FileDatasetCatalogue is a local stand-in for the metadata service : a file
+// listing dataset ids, one per line. Blank lines and '#' comments are ignored
+type FileDatasetCatalogue struct {
+	Path string
+}
+
+func (fc *FileDatasetCatalogue) DatasetIds() ([]string, error) {
+	f, err := os.Open(fc.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ids := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
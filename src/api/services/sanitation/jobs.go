@@ -0,0 +1,188 @@
+package sanitation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sm "gohan/api/models/sanitation"
+	esRepo "gohan/api/repositories/elasticsearch"
+	"gohan/api/services/vcf"
+)
+
+// bridge files older than this are left over from failed ingestions
+const staleBridgeFileAge = 24 * time.Hour
+
+// duplicates are deleted by batches of this size
+const duplicateDeletionBatchSize = 1000
+
+type job struct {
+	name string
+	run  func(report *sm.JobReport) error
+}
+
+func (ss *SanitationService) jobs() []job {
+	return []job{
+		{"duplicateVariants", ss.removeDuplicateVariants},
+		{"orphanedDatasets", ss.removeOrphanedDatasets},
+		{"staleBridgeFiles", ss.removeStaleBridgeFiles},
+	}
+}
+
+// removeDuplicateVariants removes the variant documents holding the same call as another,
+// i.e. indexed under random ids before they were derived from the call itself
+func (ss *SanitationService) removeDuplicateVariants(report *sm.JobReport) error {
+	duplicates := []string{}
+	deleteDuplicates := func() error {
+		deleted, err := esRepo.DeleteVariantsByDocumentIds(ss.Config, ss.Es7Client, duplicates)
+		if err != nil {
+			return err
+		}
+		report.Removed += deleted
+		duplicates = duplicates[:0]
+		return nil
+	}
+
+	var after map[string]interface{}
+	for {
+		groups, next, err := esRepo.GetColocatedVariantDocuments(ss.Config, ss.Es7Client, after)
+		if err != nil {
+			return err
+		}
+
+		for _, group := range groups {
+			for _, id := range DuplicateDocumentIds(group) {
+				report.Found++
+				report.AddDetail(id)
+				duplicates = append(duplicates, id)
+			}
+		}
+		if len(duplicates) >= duplicateDeletionBatchSize {
+			if err := deleteDuplicates(); err != nil {
+				return err
+			}
+		}
+
+		if next == nil {
+			break
+		}
+		after = next
+	}
+
+	if len(duplicates) > 0 {
+		return deleteDuplicates()
+	}
+	return nil
+}
+
+// DuplicateDocumentIds picks, among variant documents found at the same position (most
+// recent first), those holding the same call as another. The document stored under the
+// id derived from the call is kept, or else the most recent one
+func DuplicateDocumentIds(group []esRepo.VariantDocument) []string {
+	kept := map[string]string{} // call id -> document id
+	duplicates := []string{}
+
+	for _, doc := range group {
+		callId := vcf.DocumentId(&doc.Variant)
+
+		keptId, exists := kept[callId]
+		switch {
+		case !exists:
+			kept[callId] = doc.Id
+		case doc.Id == callId:
+			duplicates = append(duplicates, keptId)
+			kept[callId] = doc.Id
+		default:
+			duplicates = append(duplicates, doc.Id)
+		}
+	}
+	return duplicates
+}
+
+// removeOrphanedDatasets removes the variants of the datasets
+// the metadata service (or its local stand-in) no longer knows of
+func (ss *SanitationService) removeOrphanedDatasets(report *sm.JobReport) error {
+	if ss.Datasets == nil {
+		report.State = sm.Skipped
+		report.Message = "no dataset catalogue configured (GOHAN_SANITATION_DATASETS_PATH)"
+		return nil
+	}
+
+	known, err := ss.Datasets.DatasetIds()
+	if err != nil {
+		return err
+	}
+	if len(known) == 0 {
+		// most likely a misconfiguration, rather than every dataset being gone
+		report.State = sm.Skipped
+		report.Message = "the dataset catalogue is empty"
+		return nil
+	}
+
+	resultingBuckets, err := esRepo.GetVariantsBucketsByKeyword(ss.Config, ss.Es7Client, "dataset.keyword")
+	if err != nil {
+		return err
+	}
+	indexed := []string{}
+	if aggs, aggsOk := resultingBuckets["aggregations"].(map[string]interface{}); aggsOk {
+		items, _ := aggs["items"].(map[string]interface{})
+		buckets, _ := items["buckets"].([]interface{})
+		for _, bucket := range buckets {
+			if key, keyOk := bucket.(map[string]interface{})["key"].(string); keyOk {
+				indexed = append(indexed, key)
+			}
+		}
+	}
+
+	for _, dataset := range setDifference(known, indexed) {
+		report.Found++
+
+		// deleted in the background, throttled
+		taskId, err := esRepo.StartDeletingVariantsByDatasetId(ss.Config, ss.Es7Client, dataset)
+		if err != nil {
+			return err
+		}
+		report.Removed++
+		report.AddDetail(fmt.Sprintf("%s (deletion task %s)", dataset, taskId))
+	}
+	return nil
+}
+
+// removeStaleBridgeFiles removes the .vcf.gz and .tbi files
+// left in the DRS bridge directory by failed ingestions
+func (ss *SanitationService) removeStaleBridgeFiles(report *sm.JobReport) error {
+	bridgeDirectory := ss.Config.Api.BridgeDirectory
+	if bridgeDirectory == "" {
+		report.State = sm.Skipped
+		report.Message = "no bridge directory configured"
+		return nil
+	}
+
+	return filepath.Walk(bridgeDirectory, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || time.Since(info.ModTime()) < staleBridgeFileAge {
+			return nil
+		}
+
+		fileName, _ := filepath.Rel(bridgeDirectory, filePath)
+		if ss.IngestionService.FilenameAlreadyRunning(strings.TrimSuffix(fileName, ".tbi")) {
+			return nil
+		}
+
+		report.Found++
+		if err := os.Remove(filePath); err != nil {
+			fmt.Printf("Failed to remove stale bridge file %s: %s\n", filePath, err)
+			return nil
+		}
+		report.Removed++
+		report.AddDetail(fileName)
+		return nil
+	})
+}
@@ -2,27 +2,43 @@ package sanitation
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	es7 "github.com/elastic/go-elasticsearch/v7"
 	"github.com/go-co-op/gocron"
+	"github.com/google/uuid"
 
 	"gohan/api/models"
+	sm "gohan/api/models/sanitation"
+	esRepo "gohan/api/repositories/elasticsearch"
+	"gohan/api/services"
 )
 
 type (
 	SanitationService struct {
-		Initialized bool
-		Es7Client   *es7.Client
-		Config      *models.Config
+		Initialized      bool
+		Es7Client        *es7.Client
+		Config           *models.Config
+		IngestionService *services.IngestionService
+		Datasets         DatasetCatalogue // nil if none is configured
+
+		runMux  sync.Mutex
+		running bool
 	}
 )
 
-func NewSanitationService(es *es7.Client, cfg *models.Config) *SanitationService {
+var ErrAlreadyRunning = fmt.Errorf("a sanitation run is already in progress")
+
+func NewSanitationService(es *es7.Client, cfg *models.Config, iz *services.IngestionService) *SanitationService {
 	ss := &SanitationService{
-		Initialized: false,
-		Es7Client:   es,
-		Config:      cfg,
+		Initialized:      false,
+		Es7Client:        es,
+		Config:           cfg,
+		IngestionService: iz,
+	}
+	if cfg.Sanitation.DatasetsPath != "" {
+		ss.Datasets = &FileDatasetCatalogue{Path: cfg.Sanitation.DatasetsPath}
 	}
 
 	ss.Init()
@@ -39,15 +55,16 @@ func (ss *SanitationService) Init() {
 		//   context, that would mean performing something like
 		//   - removing duplicate documents
 		//   - cleaning documents that have broken pseudo-foreign keys
-		//     - variants -> tables (no longer necessary)
+		//     - variants -> datasets
 		//   etc...
 		go func() {
 			// setup cron job
 			s := gocron.NewScheduler(time.UTC)
 
-			// clean variant documents with non-existing tables
 			s.Every(1).Days().At("04:00:00").Do(func() { // 12am EST
-				// nothing for now
+				if _, err := ss.StartRun(sm.TriggerScheduled); err != nil {
+					fmt.Printf("Scheduled sanitation run not started: %s\n", err)
+				}
 			})
 
 			// starts the scheduler in blocking mode, which blocks
@@ -60,6 +77,79 @@ func (ss *SanitationService) Init() {
 	}
 }
 
+// StartRun runs the sanitation jobs in the background, one after the other, and
+// returns the report of the run, kept up to date in the 'sanitation-runs' index
+func (ss *SanitationService) StartRun(trigger string) (*sm.SanitationRun, error) {
+	ss.runMux.Lock()
+	defer ss.runMux.Unlock()
+	if ss.running {
+		return nil, ErrAlreadyRunning
+	}
+
+	run := &sm.SanitationRun{
+		Id:        uuid.New(),
+		Trigger:   trigger,
+		State:     sm.Running,
+		Jobs:      []*sm.JobReport{},
+		StartedAt: time.Now(),
+	}
+	for _, j := range ss.jobs() {
+		run.Jobs = append(run.Jobs, &sm.JobReport{Name: j.name, State: sm.Running})
+	}
+	if err := esRepo.SaveSanitationRun(ss.Config, ss.Es7Client, run); err != nil {
+		return nil, err
+	}
+	ss.running = true
+
+	// a copy is handed back, as the run carries on
+	snapshot := *run
+	snapshot.Jobs = []*sm.JobReport{}
+	for _, report := range run.Jobs {
+		reportCopy := *report
+		snapshot.Jobs = append(snapshot.Jobs, &reportCopy)
+	}
+
+	go func() {
+		defer func() {
+			ss.runMux.Lock()
+			ss.running = false
+			ss.runMux.Unlock()
+		}()
+
+		fmt.Printf("[%s] - Sanitation run %s started (%s)\n", time.Now(), run.Id, trigger)
+		run.State = sm.Done
+		for idx, j := range ss.jobs() {
+			report := run.Jobs[idx]
+			if err := j.run(report); err != nil {
+				fmt.Printf("Sanitation job %s failed: %s\n", j.name, err)
+				report.State = sm.Error
+				report.Message = err.Error()
+				run.State = sm.Error
+			} else if report.State == sm.Running {
+				report.State = sm.Done
+			}
+
+			if err := esRepo.SaveSanitationRun(ss.Config, ss.Es7Client, run); err != nil {
+				fmt.Printf("Failed to save sanitation run %s: %s\n", run.Id, err)
+			}
+		}
+
+		completedAt := time.Now()
+		run.CompletedAt = &completedAt
+		if err := esRepo.SaveSanitationRun(ss.Config, ss.Es7Client, run); err != nil {
+			fmt.Printf("Failed to save sanitation run %s: %s\n", run.Id, err)
+		}
+		fmt.Printf("[%s] - Sanitation run %s completed: %s\n", time.Now(), run.Id, run.State)
+	}()
+
+	return &snapshot, nil
+}
+
+// GetAllRuns returns the reports of the sanitation runs, most recent first
+func (ss *SanitationService) GetAllRuns() ([]*sm.SanitationRun, error) {
+	return esRepo.GetAllSanitationRuns(ss.Config, ss.Es7Client)
+}
+
 func setDifference(a, b []string) (c []string) {
 	m := make(map[string]bool)
 
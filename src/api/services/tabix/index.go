@@ -0,0 +1,403 @@
+package tabix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gohan/api/services/bgzf"
+)
+
+const (
+	minShift = 14 // 16KiB windows, at the deepest level of bins
+	tbiDepth = 5  // levels of bins below the root; .tbi indexes can't go any deeper
+
+	// .tbi indexes reach up to 2^29 (14 + 5*3) bp
+	tbiMaxPosition = int64(1) << (minShift + 3*tbiDepth)
+)
+
+// tabix configuration, as stored in both .tbi and .csi indexes
+const (
+	formatVcf   = 2
+	columnChrom = 1
+	columnPos   = 2
+	columnEnd   = 0 // computed from REF, or INFO/END
+	metaChar    = '#'
+	linesToSkip = 0
+)
+
+// Index locates the records of a sorted, BGZF-compressed VCF by position
+type Index struct {
+	depth int
+	refs  []*refIndex
+}
+
+type chunk struct {
+	begin bgzf.VirtualOffset
+	end   bgzf.VirtualOffset
+}
+
+type refIndex struct {
+	name    string
+	bins    map[uint32][]chunk
+	linear  []bgzf.VirtualOffset // by window; 0 = not set yet
+	begin   bgzf.VirtualOffset   // of the first record
+	end     bgzf.VirtualOffset   // of the last record
+	records uint64
+
+	// chunk being built : consecutive records of the same bin
+	currentBin   uint32
+	currentBegin bgzf.VirtualOffset
+	lastEnd      bgzf.VirtualOffset
+	lastStart    int64
+}
+
+// positionBeyondReachError is returned when an index
+// of the given depth can't reach a record's position
+type positionBeyondReachError struct {
+	end int64
+}
+
+func (e *positionBeyondReachError) Error() string {
+	return fmt.Sprintf("position %d is beyond the reach of the index", e.end)
+}
+
+// IsTbi tells whether the index can be written as a .tbi, rather than a .csi
+func (idx *Index) IsTbi() bool {
+	return idx.depth == tbiDepth
+}
+
+// BuildIndex indexes a BGZF-compressed VCF. Fails on unsorted files
+func BuildIndex(r io.Reader) (*Index, error) {
+	depth := tbiDepth
+	for {
+		seeker, seekable := r.(io.Seeker)
+		idx, err := buildIndex(bgzf.NewReader(r), depth)
+
+		var beyondReach *positionBeyondReachError
+		if err != nil && errors.As(err, &beyondReach) && seekable {
+			// go deeper, which only .csi indexes can
+			for beyondReach.end > int64(1)<<(minShift+3*depth) {
+				depth++
+			}
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return idx, err
+	}
+}
+
+func buildIndex(r *bgzf.Reader, depth int) (*Index, error) {
+	idx := &Index{depth: depth}
+	maxPosition := int64(1) << (minShift + 3*depth)
+
+	var (
+		ref        *refIndex
+		seen       = map[string]struct{}{}
+		lineNumber = 0
+	)
+	for {
+		begin := r.VirtualOffset()
+		line, err := r.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := r.VirtualOffset()
+		lineNumber++
+
+		if len(line) == 0 || line[0] == metaChar {
+			continue
+		}
+
+		chrom, start, stop, err := parseRecord(string(line))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if stop > maxPosition {
+			return nil, &positionBeyondReachError{end: stop}
+		}
+
+		if ref == nil || ref.name != chrom {
+			if _, exists := seen[chrom]; exists {
+				return nil, fmt.Errorf("line %d: the records of contig %s are not contiguous; the file must be sorted", lineNumber, chrom)
+			}
+			if ref != nil {
+				ref.finish()
+			}
+			seen[chrom] = struct{}{}
+			ref = &refIndex{name: chrom, bins: map[uint32][]chunk{}, begin: begin, currentBin: noBin}
+			idx.refs = append(idx.refs, ref)
+		} else if start < ref.lastStart {
+			return nil, fmt.Errorf("line %d: %s:%d comes after %s:%d; the file must be sorted", lineNumber, chrom, start+1, chrom, ref.lastStart+1)
+		}
+
+		ref.push(start, stop, begin, end, depth)
+	}
+	if ref != nil {
+		ref.finish()
+	}
+	return idx, nil
+}
+
+const noBin = ^uint32(0)
+
+func (ref *refIndex) push(start int64, stop int64, begin bgzf.VirtualOffset, end bgzf.VirtualOffset, depth int) {
+	bin := regionToBin(start, stop, depth)
+	if bin != ref.currentBin {
+		if ref.currentBin != noBin {
+			ref.bins[ref.currentBin] = append(ref.bins[ref.currentBin], chunk{ref.currentBegin, begin})
+		}
+		ref.currentBin = bin
+		ref.currentBegin = begin
+	}
+
+	// the first record overlapping each window
+	lastWindow := int((stop - 1) >> minShift)
+	for len(ref.linear) <= lastWindow {
+		ref.linear = append(ref.linear, 0)
+	}
+	for window := int(start >> minShift); window <= lastWindow; window++ {
+		if ref.linear[window] == 0 {
+			ref.linear[window] = begin
+		}
+	}
+
+	ref.lastStart = start
+	ref.lastEnd = end
+	ref.end = end
+	ref.records++
+}
+
+func (ref *refIndex) finish() {
+	ref.bins[ref.currentBin] = append(ref.bins[ref.currentBin], chunk{ref.currentBegin, ref.lastEnd})
+
+	// merge the chunks of a bin sharing a block
+	for bin, chunks := range ref.bins {
+		sort.Slice(chunks, func(i, j int) bool { return chunks[i].begin < chunks[j].begin })
+		merged := chunks[:1]
+		for _, c := range chunks[1:] {
+			last := &merged[len(merged)-1]
+			if c.begin.BlockOffset() <= last.end.BlockOffset() {
+				if c.end > last.end {
+					last.end = c.end
+				}
+				continue
+			}
+			merged = append(merged, c)
+		}
+		ref.bins[bin] = merged
+	}
+
+	// windows without records of their own start where the previous one does
+	previous := ref.begin
+	for window, offset := range ref.linear {
+		if offset == 0 {
+			ref.linear[window] = previous
+		}
+		previous = ref.linear[window]
+	}
+}
+
+// parseRecord finds where a VCF record lies : [start, stop), 0-based
+func parseRecord(line string) (string, int64, int64, error) {
+	columns := strings.SplitN(line, "\t", 9)
+	if len(columns) < 8 {
+		return "", 0, 0, fmt.Errorf("expected at least 8 columns, got %d", len(columns))
+	}
+
+	chrom := columns[0]
+	if chrom == "" {
+		return "", 0, 0, fmt.Errorf("missing CHROM")
+	}
+	pos, err := strconv.ParseInt(columns[1], 10, 64)
+	if err != nil || pos < 0 {
+		return "", 0, 0, fmt.Errorf("invalid POS '%s'", columns[1])
+	}
+
+	// POS 0 stands for a telomere, indexed as if it were at 1
+	start := pos - 1
+	if start < 0 {
+		start = 0
+	}
+	stop := start + int64(len(columns[3]))
+	if stop == start {
+		stop = start + 1
+	}
+
+	// symbolic alleles span up to INFO/END
+	for _, field := range strings.Split(columns[7], ";") {
+		if strings.HasPrefix(field, "END=") {
+			if infoEnd, err := strconv.ParseInt(field[len("END="):], 10, 64); err == nil && infoEnd > start {
+				stop = infoEnd
+			}
+			break
+		}
+	}
+	return chrom, start, stop, nil
+}
+
+// regionToBin finds the smallest bin holding [start, stop), 0-based
+func regionToBin(start int64, stop int64, depth int) uint32 {
+	stop--
+	shift := minShift
+	first := (int64(1)<<(3*depth) - 1) / 7 // of the deepest level
+	for level := depth; level > 0; level-- {
+		if start>>shift == stop>>shift {
+			return uint32(first + start>>shift)
+		}
+		shift += 3
+		first -= int64(1) << (3 * (level - 1))
+	}
+	return 0
+}
+
+// firstBinOfLevel numbers the first bin of a level, 0 being the root
+func firstBinOfLevel(level int) uint32 {
+	return uint32((int64(1)<<(3*level) - 1) / 7)
+}
+
+// binLevel finds the level of a bin, 0 being the root
+func binLevel(bin uint32) int {
+	level := 0
+	for b := bin; b > 0; b = (b - 1) >> 3 {
+		level++
+	}
+	return level
+}
+
+// metaBin is the pseudo-bin holding the offsets and record count of a contig
+func (idx *Index) metaBin() uint32 {
+	return firstBinOfLevel(idx.depth+1) + 1
+}
+
+// WriteTbi writes the index in the .tbi format, BGZF-compressed
+func (idx *Index) WriteTbi(w io.Writer) error {
+	if !idx.IsTbi() {
+		return fmt.Errorf("positions beyond %d bp can't be indexed as .tbi; use .csi instead", tbiMaxPosition)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("TBI\x01")
+	writeLE(&buf, int32(len(idx.refs)))
+	idx.writeConfiguration(&buf)
+
+	for _, ref := range idx.refs {
+		bins := ref.sortedBins()
+		writeLE(&buf, int32(len(bins)+1))
+		for _, bin := range bins {
+			writeLE(&buf, bin)
+			idx.writeChunks(&buf, ref.bins[bin])
+		}
+		idx.writeMetaBin(&buf, ref, false)
+
+		writeLE(&buf, int32(len(ref.linear)))
+		for _, offset := range ref.linear {
+			writeLE(&buf, uint64(offset))
+		}
+	}
+	writeLE(&buf, uint64(0)) // records without coordinates
+
+	return writeCompressed(w, buf.Bytes())
+}
+
+// WriteCsi writes the index in the .csi format, BGZF-compressed
+func (idx *Index) WriteCsi(w io.Writer) error {
+	var configuration bytes.Buffer
+	idx.writeConfiguration(&configuration)
+
+	var buf bytes.Buffer
+	buf.WriteString("CSI\x01")
+	writeLE(&buf, int32(minShift))
+	writeLE(&buf, int32(idx.depth))
+	writeLE(&buf, int32(configuration.Len()))
+	buf.Write(configuration.Bytes())
+	writeLE(&buf, int32(len(idx.refs)))
+
+	for _, ref := range idx.refs {
+		bins := ref.sortedBins()
+		writeLE(&buf, int32(len(bins)+1))
+		for _, bin := range bins {
+			writeLE(&buf, bin)
+			writeLE(&buf, uint64(ref.binOffset(bin, idx.depth)))
+			idx.writeChunks(&buf, ref.bins[bin])
+		}
+		idx.writeMetaBin(&buf, ref, true)
+	}
+	writeLE(&buf, uint64(0)) // records without coordinates
+
+	return writeCompressed(w, buf.Bytes())
+}
+
+func (idx *Index) writeConfiguration(buf *bytes.Buffer) {
+	var names bytes.Buffer
+	for _, ref := range idx.refs {
+		names.WriteString(ref.name)
+		names.WriteByte(0)
+	}
+
+	for _, value := range []int32{formatVcf, columnChrom, columnPos, columnEnd, metaChar, linesToSkip, int32(names.Len())} {
+		writeLE(buf, value)
+	}
+	buf.Write(names.Bytes())
+}
+
+func (idx *Index) writeChunks(buf *bytes.Buffer, chunks []chunk) {
+	writeLE(buf, int32(len(chunks)))
+	for _, c := range chunks {
+		writeLE(buf, uint64(c.begin))
+		writeLE(buf, uint64(c.end))
+	}
+}
+
+func (idx *Index) writeMetaBin(buf *bytes.Buffer, ref *refIndex, withOffset bool) {
+	writeLE(buf, idx.metaBin())
+	if withOffset {
+		writeLE(buf, uint64(0))
+	}
+	writeLE(buf, int32(2))
+	writeLE(buf, uint64(ref.begin))
+	writeLE(buf, uint64(ref.end))
+	writeLE(buf, ref.records)
+	writeLE(buf, uint64(0)) // unmapped records
+}
+
+func (ref *refIndex) sortedBins() []uint32 {
+	bins := make([]uint32, 0, len(ref.bins))
+	for bin := range ref.bins {
+		bins = append(bins, bin)
+	}
+	sort.Slice(bins, func(i, j int) bool { return bins[i] < bins[j] })
+	return bins
+}
+
+// binOffset is where the records overlapping the first window of a bin begin
+func (ref *refIndex) binOffset(bin uint32, depth int) bgzf.VirtualOffset {
+	level := binLevel(bin)
+	window := int(bin-firstBinOfLevel(level)) << (3 * (depth - level))
+	if window >= len(ref.linear) {
+		return 0
+	}
+	return ref.linear[window]
+}
+
+func writeLE(buf *bytes.Buffer, value interface{}) {
+	binary.Write(buf, binary.LittleEndian, value)
+}
+
+func writeCompressed(w io.Writer, data []byte) error {
+	bw := bgzf.NewWriter(w)
+	if _, err := bw.Write(data); err != nil {
+		return err
+	}
+	return bw.Close()
+}
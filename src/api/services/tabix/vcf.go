@@ -0,0 +1,102 @@
+package tabix
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"gohan/api/services/bgzf"
+)
+
+// IndexVcf indexes a sorted .vcf.gz, writing its index next to it : a .tbi, or a .csi when
+// positions lie beyond the reach of a .tbi. A file that's merely gzipped is re-compressed
+// as BGZF first, in place. Returns the path of the index
+func IndexVcf(gzippedFilePath string) (string, error) {
+	isBgzf, err := isBgzfFile(gzippedFilePath)
+	if err != nil {
+		return "", err
+	}
+	if !isBgzf {
+		fmt.Printf("%s is not BGZF-compressed, re-compressing it\n", path.Base(gzippedFilePath))
+		if err := recompressAsBgzf(gzippedFilePath); err != nil {
+			return "", err
+		}
+	}
+
+	f, err := os.Open(gzippedFilePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	index, err := BuildIndex(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to index %s: %w", path.Base(gzippedFilePath), err)
+	}
+
+	indexPath := gzippedFilePath + ".tbi"
+	write := index.WriteTbi
+	if !index.IsTbi() {
+		indexPath = gzippedFilePath + ".csi"
+		write = index.WriteCsi
+	}
+	if err := writeFileAtomically(indexPath, write); err != nil {
+		return "", fmt.Errorf("failed to write the index of %s: %w", path.Base(gzippedFilePath), err)
+	}
+	return indexPath, nil
+}
+
+func isBgzfFile(filePath string) (bool, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	return bgzf.IsBgzf(f)
+}
+
+// recompressAsBgzf replaces a gzipped file by its BGZF-compressed equivalent
+func recompressAsBgzf(gzippedFilePath string) error {
+	f, err := os.Open(gzippedFilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s is not gzip-compressed: %w", path.Base(gzippedFilePath), err)
+	}
+	defer gr.Close()
+
+	return writeFileAtomically(gzippedFilePath, func(w io.Writer) error {
+		bw := bgzf.NewWriter(w)
+		if _, err := io.Copy(bw, gr); err != nil {
+			return fmt.Errorf("failed to decompress %s: %w", path.Base(gzippedFilePath), err)
+		}
+		return bw.Close()
+	})
+}
+
+// writeFileAtomically writes to a temporary file, moved to 'filePath' once complete
+func writeFileAtomically(filePath string, write func(w io.Writer) error) error {
+	tmpPath := filePath + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
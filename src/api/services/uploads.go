@@ -0,0 +1,191 @@
+package services
+
+import (
+	"fmt"
+	"gohan/api/models/ingest"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// uploads are written inside the vcf path, such that they
+// can be ingested just like any other .vcf.gz found there
+const UploadsDirectory = "uploads"
+
+const (
+	// uploads receiving no chunk for that long are considered abandoned
+	uploadSessionTimeToLive    = 24 * time.Hour
+	uploadSessionSweepInterval = time.Hour
+)
+
+type uploadSession struct {
+	mux          sync.Mutex // serializes the chunks of a given upload
+	session      *ingest.VariantUploadSession
+	lastActivity time.Time
+}
+
+// UploadFileName returns the path of an upload's file, relative to the vcf path
+func UploadFileName(uploadId uuid.UUID, filename string) string {
+	return path.Join(UploadsDirectory, uploadId.String(), path.Base(filename))
+}
+
+// CreateUploadSession prepares the destination of a new resumable upload
+func (i *IngestionService) CreateUploadSession(session *ingest.VariantUploadSession) error {
+	destination := path.Join(i.Config.Api.VcfPath, UploadFileName(session.Id, session.Filename))
+	if err := os.MkdirAll(path.Dir(destination), 0700); err != nil {
+		return err
+	}
+	f, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	f.Close()
+
+	i.uploadSessionsMux.Lock()
+	i.uploadSessions[session.Id.String()] = &uploadSession{session: session, lastActivity: time.Now()}
+	i.uploadSessionsMux.Unlock()
+
+	return nil
+}
+
+// GetUploadSession returns a copy of the current state of a resumable upload
+func (i *IngestionService) GetUploadSession(uploadId uuid.UUID) (ingest.VariantUploadSession, bool) {
+	i.uploadSessionsMux.Lock()
+	defer i.uploadSessionsMux.Unlock()
+
+	us, exists := i.uploadSessions[uploadId.String()]
+	if !exists {
+		return ingest.VariantUploadSession{}, false
+	}
+
+	us.mux.Lock()
+	defer us.mux.Unlock()
+	return *us.session, true
+}
+
+// AppendToUploadSession writes a chunk starting at byte 'start' to a resumable upload.
+// Chunks must be sent in order; a chunk that doesn't start at the current offset is rejected
+func (i *IngestionService) AppendToUploadSession(uploadId uuid.UUID, start int64, chunk io.Reader) (ingest.VariantUploadSession, error) {
+	i.uploadSessionsMux.Lock()
+	us, exists := i.uploadSessions[uploadId.String()]
+	i.uploadSessionsMux.Unlock()
+	if !exists {
+		return ingest.VariantUploadSession{}, fmt.Errorf("upload %s not found", uploadId)
+	}
+
+	us.mux.Lock()
+	defer us.mux.Unlock()
+
+	if start != us.session.Offset {
+		return *us.session, fmt.Errorf("chunk starts at byte %d, but upload %s is at byte %d", start, uploadId, us.session.Offset)
+	}
+
+	destination := path.Join(i.Config.Api.VcfPath, UploadFileName(us.session.Id, us.session.Filename))
+	f, err := os.OpenFile(destination, os.O_WRONLY, 0600)
+	if err != nil {
+		return *us.session, err
+	}
+	defer f.Close()
+
+	// drop whatever may have been partially written by a previously interrupted chunk
+	if err := f.Truncate(start); err != nil {
+		return *us.session, err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return *us.session, err
+	}
+
+	// never write past the announced size
+	written, err := io.Copy(f, io.LimitReader(chunk, us.session.Size-start))
+	if err != nil {
+		return *us.session, err
+	}
+
+	us.session.Offset += written
+	us.session.UpdatedAt = time.Now().String()
+	us.lastActivity = time.Now()
+
+	return *us.session, nil
+}
+
+// RemoveUploadSession forgets about a resumable upload, and optionally deletes its file
+func (i *IngestionService) RemoveUploadSession(uploadId uuid.UUID, deleteFile bool) {
+	i.uploadSessionsMux.Lock()
+	us, exists := i.uploadSessions[uploadId.String()]
+	delete(i.uploadSessions, uploadId.String())
+	i.uploadSessionsMux.Unlock()
+
+	if exists && deleteFile {
+		os.RemoveAll(path.Join(i.Config.Api.VcfPath, UploadsDirectory, us.session.Id.String()))
+	}
+}
+
+// sweepUploadSessions periodically removes abandoned uploads, along with their partial files
+func (i *IngestionService) sweepUploadSessions() {
+	ticker := time.NewTicker(uploadSessionSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		i.RemoveExpiredUploadSessions(time.Now().Add(-uploadSessionTimeToLive))
+	}
+}
+
+// RemoveExpiredUploadSessions removes the uploads that received no chunk since 'before'.
+// Upload directories no session refers to (i.e. left over from before a restart, as sessions
+// are only kept in memory) are removed as well once as old, unless their file is being ingested
+func (i *IngestionService) RemoveExpiredUploadSessions(before time.Time) int {
+	i.uploadSessionsMux.Lock()
+	expired := []uuid.UUID{}
+	known := map[string]struct{}{}
+	for id, us := range i.uploadSessions {
+		known[id] = struct{}{}
+
+		us.mux.Lock()
+		if us.lastActivity.Before(before) {
+			expired = append(expired, us.session.Id)
+		}
+		us.mux.Unlock()
+	}
+	i.uploadSessionsMux.Unlock()
+
+	for _, id := range expired {
+		fmt.Printf("Removing abandoned upload %s\n", id)
+		i.RemoveUploadSession(id, true)
+	}
+	removed := len(expired)
+
+	uploadsPath := path.Join(i.Config.Api.VcfPath, UploadsDirectory)
+	entries, err := os.ReadDir(uploadsPath)
+	if err != nil {
+		return removed
+	}
+	for _, entry := range entries {
+		if _, isKnown := known[entry.Name()]; isKnown || !entry.IsDir() {
+			continue
+		}
+		// completed uploads are removed once ingested
+		files, _ := os.ReadDir(path.Join(uploadsPath, entry.Name()))
+		inUse := false
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil || !info.ModTime().Before(before) ||
+				i.FilenameAlreadyRunning(path.Join(UploadsDirectory, entry.Name(), file.Name())) {
+				inUse = true
+				break
+			}
+		}
+		if inUse {
+			continue
+		}
+
+		fmt.Printf("Removing leftover upload directory %s\n", entry.Name())
+		if os.RemoveAll(path.Join(uploadsPath, entry.Name())) == nil {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,78 @@
+package vcf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"gohan/api/models/indexes"
+)
+
+// ParseFormatField stores a sample's FORMAT field (other than GT, GP and PL, which are
+// handled along with the genotype) into its variation. Every field is kept in 'Formats',
+// and the common ones (DP, GQ, MIN_DP, AD, AF, SB) are also given first-class fields
+func ParseFormatField(variation *indexes.Variation, id string, raw string, definitions map[string]indexes.VcfFieldDefinition) {
+	if raw == "" {
+		return
+	}
+	variation.Formats = append(variation.Formats, *parseField(id, raw, definitions))
+
+	if raw == "." {
+		// missing altogether
+		return
+	}
+
+	switch id {
+	case "DP":
+		variation.ReadDepth = parseScalar(raw)
+	case "GQ":
+		variation.GenotypeQuality = parseScalar(raw)
+	case "MIN_DP":
+		variation.MinReadDepth = parseScalar(raw)
+	case "AD":
+		variation.AllelicDepths = parseIntegers(raw)
+	case "AF":
+		variation.AlleleFractions = parseFloats(raw)
+	case "SB":
+		variation.StrandBias = parseIntegers(raw)
+	}
+}
+
+// -- internal use only --
+
+// parseScalar reads a single integer, rounding it if need be
+// (some callers emit floating point qualities), or nil if missing
+func parseScalar(raw string) *int64 {
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	n := int64(math.Round(f))
+	return &n
+}
+
+// parseIntegers reads a comma separated list, where -1 = no call (equivalent to a '.')
+func parseIntegers(raw string) []int64 {
+	var values []int64
+	for _, v := range strings.Split(raw, ",") {
+		if n := parseScalar(v); n != nil {
+			values = append(values, *n)
+		} else {
+			values = append(values, -1)
+		}
+	}
+	return values
+}
+
+// parseFloats reads a comma separated list, where -1 = no call (equivalent to a '.')
+func parseFloats(raw string) []float64 {
+	var values []float64
+	for _, v := range strings.Split(raw, ",") {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			values = append(values, f)
+		} else {
+			values = append(values, -1)
+		}
+	}
+	return values
+}
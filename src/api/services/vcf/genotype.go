@@ -0,0 +1,137 @@
+package vcf
+
+import (
+	"strconv"
+	"strings"
+
+	"gohan/api/models/constants"
+	z "gohan/api/models/constants/zygosity"
+	"gohan/api/models/indexes"
+)
+
+// ParseGenotype reads a GT value of any ploidy, i.e. '1', '0/1', '0|1|2' or '0/1|1/2'
+// (as found in polyploid organisms and tumour samples), following
+// https://samtools.github.io/hts-specs/VCFv4.3.pdf , section 1.6.2
+//
+// Missing alleles ('.') and unparsable ones are given an index of -1, unless all of
+// them are missing, in which case the call is treated as a reference call, as it always has been
+func ParseGenotype(gt string) indexes.Genotype {
+	var (
+		alleleStrings    []string
+		phasedSeparators []bool
+		current          strings.Builder
+	)
+	for _, r := range gt {
+		if r == '|' || r == '/' {
+			alleleStrings = append(alleleStrings, current.String())
+			phasedSeparators = append(phasedSeparators, r == '|')
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	alleleStrings = append(alleleStrings, current.String())
+
+	genotype := indexes.Genotype{
+		Ploidy:           constants.Ploidy(len(alleleStrings)),
+		AlleleIndexes:    make([]int, len(alleleStrings)),
+		PhasedSeparators: phasedSeparators,
+	}
+
+	// -- phase : a call is phased only if all of its alleles are
+	genotype.Phased = len(phasedSeparators) > 0
+	for _, phased := range phasedSeparators {
+		genotype.Phased = genotype.Phased && phased
+	}
+
+	// -- alleles
+	missingCount := 0
+	for i, s := range alleleStrings {
+		if s == "." {
+			missingCount++
+			genotype.AlleleIndexes[i] = -1
+		} else if index, err := strconv.Atoi(s); err == nil && index >= 0 {
+			genotype.AlleleIndexes[i] = index
+		} else {
+			// probably an unknown character
+			genotype.AlleleIndexes[i] = -1
+		}
+	}
+	if missingCount == len(alleleStrings) {
+		for i := range genotype.AlleleIndexes {
+			genotype.AlleleIndexes[i] = 0
+		}
+	}
+
+	genotype.Zygosity = Zygosity(genotype.AlleleIndexes)
+	return genotype
+}
+
+// Zygosity classifies a call given its allele indexes (0 = reference, -1 = unknown)
+func Zygosity(alleleIndexes []int) constants.Zygosity {
+	if len(alleleIndexes) == 0 {
+		return z.Unknown
+	}
+
+	var (
+		hasReference bool
+		allSame      = true
+	)
+	for _, index := range alleleIndexes {
+		if index < 0 {
+			return z.Unknown
+		}
+		if index == 0 {
+			hasReference = true
+		}
+		if index != alleleIndexes[0] {
+			allSame = false
+		}
+	}
+
+	// Haploid
+	if len(alleleIndexes) == 1 {
+		if hasReference {
+			return z.Reference
+		}
+		return z.Alternate
+	}
+
+	// Diploid or higher
+	switch {
+	case allSame && hasReference:
+		return z.HomozygousReference
+	case allSame:
+		return z.HomozygousAlternate
+	case hasReference:
+		return z.Heterozygous
+	default:
+		// i.e. 1/2, or 1/1/2 : two or more distinct alternate alleles
+		return z.HeterozygousAlternate
+	}
+}
+
+// IsReferenceCall is true of any call made of reference alleles only, regardless of ploidy
+func IsReferenceCall(gt string) bool {
+	for _, s := range strings.FieldsFunc(gt, func(r rune) bool { return r == '|' || r == '/' }) {
+		if s != "0" {
+			return false
+		}
+	}
+	return gt != ""
+}
+
+// GenotypeAlleles resolves allele indexes into the alleles themselves; the
+// reference allele (index 0) is the first of 'ref' and others come from 'alt'.
+// As with the reference calls, unknown and out-of-range indexes resolve to the reference
+func GenotypeAlleles(alleleIndexes []int, ref []string, alt []string) []string {
+	alleles := make([]string, len(alleleIndexes))
+	for i, index := range alleleIndexes {
+		if index > 0 && index <= len(alt) {
+			alleles[i] = alt[index-1]
+		} else if len(ref) > 0 {
+			alleles[i] = ref[0]
+		}
+	}
+	return alleles
+}
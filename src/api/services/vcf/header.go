@@ -0,0 +1,177 @@
+package vcf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gohan/api/models/indexes"
+)
+
+// Value types of INFO and FORMAT fields, as declared in the VCF header
+const (
+	Integer   = "Integer"
+	Float     = "Float"
+	Flag      = "Flag"
+	Character = "Character"
+	String    = "String"
+)
+
+// Header gathers the meta-information block and the sample ids of a VCF
+type Header struct {
+	Info      map[string]indexes.VcfFieldDefinition
+	Format    map[string]indexes.VcfFieldDefinition
+	SampleIds []string
+
+	document indexes.VcfHeader
+}
+
+func NewHeader() *Header {
+	return &Header{
+		Info:   map[string]indexes.VcfFieldDefinition{},
+		Format: map[string]indexes.VcfFieldDefinition{},
+		document: indexes.VcfHeader{
+			Contigs:   []indexes.VcfContig{},
+			Info:      []indexes.VcfFieldDefinition{},
+			Format:    []indexes.VcfFieldDefinition{},
+			MetaLines: []string{},
+		},
+	}
+}
+
+// AddMetaLine records a '##' meta-information line
+func (h *Header) AddMetaLine(line string) {
+	h.document.MetaLines = append(h.document.MetaLines, line)
+
+	if key, value, found := strings.Cut(strings.TrimPrefix(line, "##"), "="); found {
+		switch key {
+		case "fileformat":
+			h.document.FileFormat = value
+		case "reference":
+			h.document.Reference = value
+		}
+	}
+
+	key, fields, ok := ParseStructuredMetaLine(line)
+	if !ok || fields["ID"] == "" {
+		return
+	}
+
+	switch key {
+	case "contig":
+		contig := indexes.VcfContig{Id: fields["ID"]}
+		if length, err := strconv.ParseInt(fields["length"], 10, 64); err == nil {
+			contig.Length = length
+		}
+		h.document.Contigs = append(h.document.Contigs, contig)
+
+	case "INFO", "FORMAT":
+		definition := indexes.VcfFieldDefinition{
+			Id:          fields["ID"],
+			Number:      fields["Number"],
+			Type:        fields["Type"],
+			Description: fields["Description"],
+		}
+		if key == "INFO" {
+			h.Info[definition.Id] = definition
+			h.document.Info = append(h.document.Info, definition)
+		} else {
+			h.Format[definition.Id] = definition
+			h.document.Format = append(h.document.Format, definition)
+		}
+	}
+}
+
+// AddColumnsLine records the sample ids found in the '#CHROM' line,
+// i.e. every column following the 9 mandatory ones (up to FORMAT)
+func (h *Header) AddColumnsLine(line string) {
+	columns := strings.Split(line, "\t")
+	if len(columns) > 9 {
+		h.SampleIds = append([]string{}, columns[9:]...)
+	} else {
+		h.SampleIds = []string{}
+	}
+}
+
+// ReadHeader reads the header of a (decompressed) VCF, up to and including the '#CHROM' line
+func ReadHeader(r io.Reader) (*Header, error) {
+	header := NewHeader()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) // '#CHROM' lines of large cohorts are long
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "##") {
+			header.AddMetaLine(line)
+			continue
+		}
+		if strings.HasPrefix(line, "#CHROM") {
+			header.AddColumnsLine(line)
+			return header, nil
+		}
+		break
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no '#CHROM' header line found")
+}
+
+// Document returns the meta-information block, as stored along with each ingested file
+func (h *Header) Document() indexes.VcfHeader {
+	return h.document
+}
+
+// ParseStructuredMetaLine splits a structured meta-information line, i.e.
+// ##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth, across samples">
+// into its key ("INFO") and fields. Quoted values may contain commas and escaped quotes
+func ParseStructuredMetaLine(line string) (string, map[string]string, bool) {
+	if !strings.HasPrefix(line, "##") {
+		return "", nil, false
+	}
+
+	key, value, found := strings.Cut(strings.TrimPrefix(line, "##"), "=")
+	if !found || !strings.HasPrefix(value, "<") || !strings.HasSuffix(value, ">") {
+		return "", nil, false
+	}
+	value = value[1 : len(value)-1]
+
+	fields := map[string]string{}
+	var (
+		current  strings.Builder
+		name     string
+		inQuotes bool
+		escaped  bool
+	)
+	flush := func() {
+		if name != "" {
+			fields[name] = current.String()
+		}
+		name = ""
+		current.Reset()
+	}
+
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case inQuotes && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && r == '=' && name == "":
+			name = strings.TrimSpace(current.String())
+			current.Reset()
+		case !inQuotes && r == ',':
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return key, fields, true
+}
@@ -0,0 +1,48 @@
+package vcf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"gohan/api/models/indexes"
+)
+
+// DocumentId derives the id of a variant document from what identifies a call, i.e.
+// its dataset, assembly, position, alleles and sample, such that re-ingesting a
+// file overwrites its variants rather than duplicating them
+func DocumentId(variant *indexes.Variant) string {
+	return hashParts(
+		variant.Dataset,
+		variant.AssemblyId,
+		variant.Chrom,
+		strconv.Itoa(variant.Pos),
+		strings.Join(variant.Ref, ","),
+		strings.Join(variant.Alt, ","),
+		variant.Sample.Id,
+	)
+}
+
+// CoverageDocumentId derives the id of a gVCF reference block document, as DocumentId does
+func CoverageDocumentId(coverage *indexes.Coverage) string {
+	return hashParts(
+		coverage.Dataset,
+		coverage.AssemblyId,
+		coverage.Chrom,
+		strconv.Itoa(coverage.Start),
+		strconv.Itoa(coverage.End),
+		coverage.SampleId,
+	)
+}
+
+// hashParts returns the hex encoded sha256 of the given parts, separated such
+// that i.e. ("1", "23") and ("12", "3") don't collide
+func hashParts(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,122 @@
+package vcf
+
+import (
+	"strconv"
+	"strings"
+
+	"gohan/api/models/indexes"
+)
+
+// TypedValues holds the values of an INFO or FORMAT field converted to their declared
+// type. Missing values ('.') are left out, the raw string keeps track of their positions
+type TypedValues struct {
+	Integers []int64
+	Floats   []float64
+	Strings  []string
+}
+
+// ParseTypedValues converts the comma-separated values of a field to the given type.
+// The second return value is false if any of them doesn't match that type
+func ParseTypedValues(raw string, valueType string) (TypedValues, bool) {
+	var typed TypedValues
+
+	for _, v := range strings.Split(raw, ",") {
+		if v == "." || v == "" {
+			continue
+		}
+
+		switch valueType {
+		case Integer:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return TypedValues{}, false
+			}
+			typed.Integers = append(typed.Integers, n)
+		case Float:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return TypedValues{}, false
+			}
+			typed.Floats = append(typed.Floats, f)
+		case Character, String:
+			typed.Strings = append(typed.Strings, v)
+		default:
+			return TypedValues{}, false
+		}
+	}
+
+	return typed, true
+}
+
+// ParseInfo splits the INFO column of a VCF row into its fields. Fields declared
+// in the header are also stored with their declared type, such that numeric ones
+// can be range-filtered and aggregated. Undeclared fields are kept as strings only
+func ParseInfo(value string, definitions map[string]indexes.VcfFieldDefinition) []*indexes.Info {
+	var allInfos []*indexes.Info
+
+	// Split all fields by semi-colon
+	for _, scSep := range strings.Split(value, ";") {
+		// Split by equality symbol
+		id, raw, hasValue := strings.Cut(scSep, "=")
+		definition, declared := definitions[id]
+
+		if !hasValue {
+			if declared && definition.Type == Flag {
+				allInfos = append(allInfos, &indexes.Info{
+					Id:   id,
+					Type: Flag,
+					Flag: true,
+				})
+			} else {
+				// kept as is, for backwards compatibility
+				allInfos = append(allInfos, &indexes.Info{
+					Id:    "",
+					Value: id,
+				})
+			}
+			continue
+		}
+
+		allInfos = append(allInfos, parseField(id, raw, definitions))
+	}
+
+	return allInfos
+}
+
+// NumericValuesById gathers the numeric values of the declared fields, keyed by field id, such
+// that a given field (i.e. DP or AF) can be range-filtered without matching the values of another
+func NumericValuesById(fields []indexes.Info) map[string][]float64 {
+	var values map[string][]float64
+	for _, f := range fields {
+		if f.Id == "" || (len(f.Integers) == 0 && len(f.Floats) == 0) {
+			continue
+		}
+		if values == nil {
+			values = map[string][]float64{}
+		}
+		for _, n := range f.Integers {
+			values[f.Id] = append(values[f.Id], float64(n))
+		}
+		values[f.Id] = append(values[f.Id], f.Floats...)
+	}
+	return values
+}
+
+// parseField keeps the raw value of an INFO or FORMAT field, along
+// with its typed values if the field is declared in the header
+func parseField(id string, raw string, definitions map[string]indexes.VcfFieldDefinition) *indexes.Info {
+	field := &indexes.Info{
+		Id:    id,
+		Value: raw,
+	}
+	if definition, declared := definitions[id]; declared {
+		if typed, ok := ParseTypedValues(raw, definition.Type); ok {
+			field.Type = definition.Type
+			field.Number = definition.Number
+			field.Integers = typed.Integers
+			field.Floats = typed.Floats
+			field.Strings = typed.Strings
+		}
+	}
+	return field
+}
@@ -0,0 +1,338 @@
+package vcf
+
+import (
+	"fmt"
+	"strings"
+
+	"gohan/api/models/indexes"
+	"gohan/api/utils"
+)
+
+// Number of values of the reserved INFO and FORMAT fields affected by decomposition,
+// for files which don't declare them in their header
+var reservedNumbers = map[string]string{
+	"AC":  "A",
+	"AF":  "A",
+	"AD":  "R",
+	"ADF": "R",
+	"ADR": "R",
+	"GL":  "G",
+	"GP":  "G",
+	"PL":  "G",
+}
+
+// Decompose splits a multi-allelic variant into one biallelic variant per alternate
+// allele, as 'bcftools norm -m-' does. Genotypes are recalculated such that the
+// remaining alternate allele becomes '1' and every other one becomes the reference
+// ('0'), while per-allele (Number=A and R) and per-genotype (Number=G) values are
+// narrowed down accordingly. The original record is kept as the OLD_MULTIALLELIC INFO field
+func Decompose(variant indexes.Variant) []indexes.Variant {
+	if len(variant.Alt) < 2 || len(variant.Ref) == 0 {
+		return []indexes.Variant{variant}
+	}
+
+	origin := indexes.Info{
+		Id:    "OLD_MULTIALLELIC",
+		Value: fmt.Sprintf("%s:%d:%s/%s", variant.Chrom, variant.Pos, variant.Ref[0], strings.Join(variant.Alt, "/")),
+	}
+
+	decomposed := make([]indexes.Variant, 0, len(variant.Alt))
+	for altIndex := 1; altIndex <= len(variant.Alt); altIndex++ {
+		v := variant
+		v.Alt = []string{variant.Alt[altIndex-1]}
+
+		v.Info = make([]indexes.Info, 0, len(variant.Info)+1)
+		for _, info := range variant.Info {
+			v.Info = append(v.Info, narrowField(info, altIndex, len(variant.Alt), 0))
+		}
+		v.Info = append(v.Info, origin)
+
+		v.Sample.Variation = decomposeVariation(variant.Sample.Variation, altIndex, len(variant.Alt), v.Ref, v.Alt)
+
+		decomposed = append(decomposed, v)
+	}
+	return decomposed
+}
+
+// Normalize trims the bases shared by the REF and ALT alleles of a variant,
+// such that equivalent indels reported differently by different callers are
+// indexed identically (i.e. POS=100 REF=CTT ALT=CT becomes POS=100 REF=CT ALT=C)
+func Normalize(variant *indexes.Variant) {
+	if len(variant.Ref) == 0 {
+		return
+	}
+
+	pos, ref, alt := NormalizeAlleles(variant.Pos, variant.Ref[0], variant.Alt)
+	if pos == variant.Pos && ref == variant.Ref[0] {
+		return
+	}
+
+	// the end coordinate follows the REF allele, unless it was given by the END INFO field
+	if variant.End == variant.Pos+len(variant.Ref[0])-1 {
+		variant.End = pos + len(ref) - 1
+	}
+
+	variant.Pos = pos
+	variant.Ref = []string{ref}
+	variant.Alt = alt
+
+	variation := &variant.Sample.Variation
+	variation.AllAlleles = GenotypeAlleles(variation.Genotype.AlleleIndexes, variant.Ref, variant.Alt)
+	resetAllelePair(variation)
+}
+
+// NormalizeAlleles trims the trailing, then leading, bases shared by the reference and
+// all alternate alleles, leaving at least one base in each. Symbolic alleles, breakends
+// and '*' are left as is, along with any record holding one of them
+func NormalizeAlleles(pos int, ref string, alt []string) (int, string, []string) {
+	if len(alt) == 0 {
+		return pos, ref, alt
+	}
+	for _, a := range alt {
+		if a == "" || a == "*" || a == "." || strings.ContainsAny(a, "<>[]") {
+			return pos, ref, alt
+		}
+	}
+
+	alleles := append([]string{ref}, alt...)
+	shortest := func() int {
+		min := len(alleles[0])
+		for _, a := range alleles[1:] {
+			if len(a) < min {
+				min = len(a)
+			}
+		}
+		return min
+	}
+
+	// -- trailing bases
+	for shortest() > 1 {
+		last := alleles[0][len(alleles[0])-1]
+		shared := true
+		for _, a := range alleles[1:] {
+			shared = shared && a[len(a)-1] == last
+		}
+		if !shared {
+			break
+		}
+		for i := range alleles {
+			alleles[i] = alleles[i][:len(alleles[i])-1]
+		}
+	}
+
+	// -- leading bases
+	for shortest() > 1 {
+		first := alleles[0][0]
+		shared := true
+		for _, a := range alleles[1:] {
+			shared = shared && a[0] == first
+		}
+		if !shared {
+			break
+		}
+		for i := range alleles {
+			alleles[i] = alleles[i][1:]
+		}
+		pos++
+	}
+
+	return pos, alleles[0], alleles[1:]
+}
+
+// LeftAlign shifts an indel to its leftmost equivalent position, as 'vt normalize'
+// and 'bcftools norm -f' do (i.e. deleting the second 'CA' of 'GCACA' is reported as
+// deleting the first), given access to the bases preceding it. The alleles are then
+// trimmed as by NormalizeAlleles. Symbolic alleles and SNVs/MNPs are left as is
+func LeftAlign(pos int, ref string, alt []string, baseAt func(pos int) (byte, bool)) (int, string, []string) {
+	if len(alt) == 0 {
+		return pos, ref, alt
+	}
+	isIndel := false
+	for _, a := range alt {
+		if a == "" || a == "*" || a == "." || strings.ContainsAny(a, "<>[]") {
+			return pos, ref, alt
+		}
+		isIndel = isIndel || len(a) != len(ref)
+	}
+	if !isIndel {
+		return pos, ref, alt
+	}
+
+	alleles := append([]string{ref}, alt...)
+	for {
+		changed := false
+
+		// -- drop the last base if shared by all alleles
+		shared := true
+		for _, a := range alleles {
+			shared = shared && len(a) > 0 && a[len(a)-1] == alleles[0][len(alleles[0])-1]
+		}
+		if shared {
+			for i := range alleles {
+				alleles[i] = alleles[i][:len(alleles[i])-1]
+			}
+			changed = true
+		}
+
+		// -- extend to the left if any allele is now empty
+		for _, a := range alleles {
+			if len(a) > 0 {
+				continue
+			}
+			base, ok := baseAt(pos - 1)
+			if !ok {
+				// can't go any further left; undo a trailing trim that would leave an empty allele
+				return NormalizeAlleles(pos, ref, alt)
+			}
+			for i := range alleles {
+				alleles[i] = string(base) + alleles[i]
+			}
+			pos--
+			changed = true
+			break
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	return NormalizeAlleles(pos, alleles[0], alleles[1:])
+}
+
+// RefMatches tells whether a REF allele matches the reference genome, ambiguous
+// bases (i.e. 'N') of either one matching anything
+func RefMatches(ref string, reference string) bool {
+	if len(ref) != len(reference) {
+		return false
+	}
+	for i := 0; i < len(ref); i++ {
+		r, g := ref[i]&^0x20, reference[i]&^0x20 // upper case
+		if r != g && isUnambiguousBase(r) && isUnambiguousBase(g) {
+			return false
+		}
+	}
+	return true
+}
+
+func isUnambiguousBase(b byte) bool {
+	return b == 'A' || b == 'C' || b == 'G' || b == 'T'
+}
+
+func decomposeVariation(variation indexes.Variation, altIndex int, altCount int, ref []string, alt []string) indexes.Variation {
+	v := variation
+	genotype := variation.Genotype
+
+	// -- genotype
+	v.Genotype.AlleleIndexes = make([]int, len(genotype.AlleleIndexes))
+	for i, index := range genotype.AlleleIndexes {
+		switch index {
+		case -1, 0:
+			v.Genotype.AlleleIndexes[i] = index
+		case altIndex:
+			v.Genotype.AlleleIndexes[i] = 1
+		default:
+			v.Genotype.AlleleIndexes[i] = 0
+		}
+	}
+	v.Genotype.Zygosity = Zygosity(v.Genotype.AlleleIndexes)
+	v.AllAlleles = GenotypeAlleles(v.Genotype.AlleleIndexes, ref, alt)
+	resetAllelePair(&v)
+
+	// -- per-allele and per-genotype values
+	ploidy := len(genotype.AlleleIndexes)
+	v.AllelicDepths = pick(variation.AllelicDepths, narrowedIndexes("R", altIndex, altCount, ploidy))
+	v.AlleleFractions = pick(variation.AlleleFractions, narrowedIndexes("A", altIndex, altCount, ploidy))
+	v.GenotypeProbability = pick(variation.GenotypeProbability, narrowedIndexes("G", altIndex, altCount, ploidy))
+	v.PhredScaleLikelyhood = pick(variation.PhredScaleLikelyhood, narrowedIndexes("G", altIndex, altCount, ploidy))
+
+	if variation.Formats != nil {
+		v.Formats = make([]indexes.Info, 0, len(variation.Formats))
+		for _, f := range variation.Formats {
+			v.Formats = append(v.Formats, narrowField(f, altIndex, altCount, ploidy))
+		}
+	}
+
+	return v
+}
+
+// narrowField keeps the values of an INFO or FORMAT field relevant to a single alternate allele
+func narrowField(field indexes.Info, altIndex int, altCount int, ploidy int) indexes.Info {
+	number := field.Number
+	if number == "" {
+		number = reservedNumbers[field.Id]
+	}
+	if number != "A" && number != "R" && number != "G" {
+		return field
+	}
+
+	values := strings.Split(field.Value, ",")
+	narrowed := pick(values, narrowedIndexes(number, altIndex, altCount, ploidy))
+	if narrowed == nil {
+		// per-genotype values of a ploidy other than 1 or 2 can't be narrowed down
+		return indexes.Info{Id: field.Id, Value: ".", Type: field.Type, Number: field.Number}
+	}
+
+	field.Value = strings.Join(narrowed, ",")
+	if field.Type != "" {
+		typed, _ := ParseTypedValues(field.Value, field.Type)
+		field.Integers = typed.Integers
+		field.Floats = typed.Floats
+		field.Strings = typed.Strings
+	}
+	return field
+}
+
+// narrowedIndexes lists the positions of the values relevant to a single
+// alternate allele (1-based) given the Number of a field, or nil if
+// unknown. Values of other Numbers are all kept (-1)
+func narrowedIndexes(number string, altIndex int, altCount int, ploidy int) []int {
+	switch number {
+	case "A":
+		return []int{altIndex - 1}
+	case "R":
+		return []int{0, altIndex}
+	case "G":
+		switch ploidy {
+		case 1:
+			return []int{0, altIndex}
+		case 2:
+			// ordering of diploid genotypes, as per the VCF spec : F(j/k) = k(k+1)/2 + j
+			het := altIndex * (altIndex + 1) / 2
+			return []int{0, het, het + altIndex}
+		}
+		return nil
+	}
+	return []int{-1}
+}
+
+// pick narrows values down to those at the given positions. Values are
+// kept as is if the positions are -1 (all) or don't fit (malformed record)
+func pick[T any](values []T, positions []int) []T {
+	if len(values) == 0 || (len(positions) == 1 && positions[0] == -1) {
+		return values
+	}
+	if positions == nil {
+		return nil
+	}
+
+	picked := make([]T, 0, len(positions))
+	for _, p := range positions {
+		if p < 0 || p >= len(values) {
+			return values
+		}
+		picked = append(picked, values[p])
+	}
+	return picked
+}
+
+func resetAllelePair(variation *indexes.Variation) {
+	variation.Alleles = indexes.AllelePair{}
+	if len(variation.AllAlleles) > 0 {
+		variation.Alleles.Left = variation.AllAlleles[0]
+	}
+	if len(variation.AllAlleles) > 1 {
+		variation.Alleles.Right = variation.AllAlleles[1]
+	}
+	variation.AlleleOccurrences = utils.NumberOccurrences(variation.AllAlleles)
+}
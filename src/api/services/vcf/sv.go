@@ -0,0 +1,100 @@
+package vcf
+
+import (
+	"strconv"
+	"strings"
+
+	"gohan/api/models/indexes"
+)
+
+// Extent describes the span of a variant on its contig, along with its
+// structural variant type and length when it is one (i.e. <DEL>, <DUP>, breakends)
+type Extent struct {
+	End    int
+	SvType string
+	SvLen  int
+}
+
+// VariantExtent determines the (1-based, inclusive) end coordinate of a variant,
+// from, in order of precedence :
+//   - its END INFO field
+//   - its SVLEN INFO field (not applicable to insertions, which span their position only)
+//   - the length of its REF allele
+//
+// SVTYPE is taken from the INFO field of the same name, or otherwise
+// derived from the first symbolic ALT allele ('<DEL:ME>' -> 'DEL', breakends -> 'BND')
+func VariantExtent(pos int, ref []string, alt []string, infos []*indexes.Info) Extent {
+	extent := Extent{End: pos}
+	if len(ref) > 0 && len(ref[0]) > 0 {
+		extent.End = pos + len(ref[0]) - 1
+	}
+
+	var (
+		end, svLen       int
+		hasEnd, hasSvLen bool
+	)
+	for _, info := range infos {
+		if info == nil {
+			continue
+		}
+		switch info.Id {
+		case "END":
+			end, hasEnd = firstInteger(info)
+		case "SVLEN":
+			svLen, hasSvLen = firstInteger(info)
+		case "SVTYPE":
+			extent.SvType = info.Value
+		}
+	}
+
+	if extent.SvType == "" {
+		extent.SvType = symbolicAltType(alt)
+	}
+
+	if hasSvLen {
+		// negative for deletions, as per VCF 4.3
+		if svLen < 0 {
+			svLen = -svLen
+		}
+		extent.SvLen = svLen
+	}
+
+	switch {
+	case hasEnd && end >= pos:
+		extent.End = end
+	case hasSvLen && extent.SvType != "INS":
+		extent.End = pos + svLen
+	}
+
+	return extent
+}
+
+// firstInteger reads the first value of an Integer field, whether
+// declared in the header (and thus already typed) or not
+func firstInteger(info *indexes.Info) (int, bool) {
+	if len(info.Integers) > 0 {
+		return int(info.Integers[0]), true
+	}
+	first, _, _ := strings.Cut(info.Value, ",")
+	n, err := strconv.Atoi(first)
+	return n, err == nil
+}
+
+func symbolicAltType(alt []string) string {
+	for _, a := range alt {
+		switch {
+		case strings.HasPrefix(a, "<") && strings.HasSuffix(a, ">") && a != "<*>" && a != "<NON_REF>":
+			svType, _, _ := strings.Cut(a[1:len(a)-1], ":")
+			return svType
+		case strings.ContainsAny(a, "[]"):
+			return "BND"
+		}
+	}
+	return ""
+}
+
+// IsReferenceBlock is true of gVCF reference blocks, whose only ALT
+// allele is the symbolic '<NON_REF>' (GATK) or '<*>' (bcftools, DeepVariant)
+func IsReferenceBlock(alt []string) bool {
+	return len(alt) == 1 && (alt[0] == "<NON_REF>" || alt[0] == "<*>")
+}
@@ -0,0 +1,188 @@
+package vcf
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gohan/api/models/ingest"
+)
+
+// MaxReportedErrors caps the number of line-level errors listed in a validation
+// report, beyond which they are only counted
+const MaxReportedErrors = 100
+
+var (
+	refAlleleRegexp = regexp.MustCompile(`^[ACGTNacgtn]+$`)
+	altAlleleRegexp = regexp.MustCompile(`^([ACGTNacgtn]+|\*|\.|<[^<>]+>|\.?[ACGTNacgtn]+\.?|.*[\[\]].*)$`) // bases, symbolic alleles or breakends
+)
+
+// ValidateRow checks the 8 fixed columns of a data line (CHROM to INFO),
+// returning the reasons it is malformed, if any. Samples are checked
+// separately, see ValidateGenotype
+func ValidateRow(columns []string) []string {
+	if len(columns) < 8 {
+		return []string{fmt.Sprintf("expected at least 8 columns, got %d", len(columns))}
+	}
+
+	var reasons []string
+	if chrom := strings.TrimSpace(columns[0]); chrom == "" || chrom == "." {
+		reasons = append(reasons, "missing CHROM")
+	}
+	if pos, err := strconv.ParseInt(strings.TrimSpace(columns[1]), 10, 64); err != nil || pos < 0 {
+		reasons = append(reasons, fmt.Sprintf("invalid POS '%s'", columns[1]))
+	}
+	if ref := strings.TrimSpace(columns[3]); !refAlleleRegexp.MatchString(ref) {
+		reasons = append(reasons, fmt.Sprintf("invalid REF '%s'", ref))
+	}
+	for _, alt := range strings.Split(strings.TrimSpace(columns[4]), ",") {
+		if !altAlleleRegexp.MatchString(alt) {
+			reasons = append(reasons, fmt.Sprintf("invalid ALT '%s'", alt))
+			break
+		}
+	}
+	if qual := strings.TrimSpace(columns[5]); qual != "." {
+		if q, err := strconv.ParseFloat(qual, 64); err != nil || math.IsNaN(q) {
+			reasons = append(reasons, fmt.Sprintf("invalid QUAL '%s'", qual))
+		}
+	}
+	return reasons
+}
+
+// ValidateGenotype checks that a GT value is made of missing ('.') alleles
+// and indexes of the REF (0) and ALT (1 to altCount) alleles only
+func ValidateGenotype(gt string, altCount int) error {
+	if gt == "" {
+		return fmt.Errorf("empty genotype")
+	}
+	for _, s := range strings.FieldsFunc(gt, func(r rune) bool { return r == '|' || r == '/' }) {
+		if s == "." {
+			continue
+		}
+		index, err := strconv.Atoi(s)
+		if err != nil || index < 0 {
+			return fmt.Errorf("invalid allele '%s' in genotype '%s'", s, gt)
+		}
+		if index > altCount {
+			return fmt.Errorf("allele %d of genotype '%s' is out of range (%d ALT alleles)", index, gt, altCount)
+		}
+	}
+	if strings.HasPrefix(gt, "/") || strings.HasPrefix(gt, "|") ||
+		strings.HasSuffix(gt, "/") || strings.HasSuffix(gt, "|") ||
+		strings.Contains(gt, "//") || strings.Contains(gt, "||") {
+		return fmt.Errorf("missing allele in genotype '%s'", gt)
+	}
+	return nil
+}
+
+// Validator accumulates the problems found while reading a VCF into a
+// validation report. It is safe for concurrent use
+type Validator struct {
+	mux sync.Mutex
+
+	report          ingest.VcfValidationReport
+	contigsSeen     map[string]struct{}
+	unknownContigs  map[string]struct{}
+	declaredContigs map[string]struct{}
+	knownContig     func(contig string) bool
+}
+
+func NewValidator(filename string) *Validator {
+	return &Validator{
+		report: ingest.VcfValidationReport{
+			Filename: filename,
+			Errors:   []ingest.VcfLineError{},
+		},
+		contigsSeen:     map[string]struct{}{},
+		unknownContigs:  map[string]struct{}{},
+		declaredContigs: map[string]struct{}{},
+	}
+}
+
+// SetHeader takes note of the samples and of the contigs declared by a fully read header
+func (v *Validator) SetHeader(header *Header) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.report.SampleCount = len(header.SampleIds)
+	for _, contig := range header.Document().Contigs {
+		v.declaredContigs[strings.TrimPrefix(contig.Id, "chr")] = struct{}{}
+	}
+}
+
+// SetKnownContig provides a lookup of the contigs of the reference genome, if any
+func (v *Validator) SetKnownContig(knownContig func(contig string) bool) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.knownContig = knownContig
+}
+
+// Record counts a data line, and the contig it is found on
+func (v *Validator) Record(contig string) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.report.RecordCount++
+	if _, seen := v.contigsSeen[contig]; seen {
+		return
+	}
+	v.contigsSeen[contig] = struct{}{}
+
+	_, declared := v.declaredContigs[strings.TrimPrefix(contig, "chr")]
+	if (len(v.declaredContigs) > 0 && !declared) || (v.knownContig != nil && !v.knownContig(contig)) {
+		v.unknownContigs[contig] = struct{}{}
+	}
+}
+
+// LineError reports a problem found on a line (1-based), or with the whole file if 0
+func (v *Validator) LineError(line int64, reason string) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.lineError(line, reason)
+}
+
+// MalformedGenotype reports an invalid GT value of a sample
+func (v *Validator) MalformedGenotype(line int64, sampleId string, err error) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.report.MalformedGenotypes++
+	v.lineError(line, fmt.Sprintf("sample %s: %s", sampleId, err))
+}
+
+// Report returns the problems found so far, with lines and contigs sorted
+func (v *Validator) Report() ingest.VcfValidationReport {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	report := v.report
+	report.Errors = append([]ingest.VcfLineError{}, v.report.Errors...)
+	sort.SliceStable(report.Errors, func(a, b int) bool { return report.Errors[a].Line < report.Errors[b].Line })
+
+	report.ContigsSeen = sortedKeys(v.contigsSeen)
+	report.UnknownContigs = sortedKeys(v.unknownContigs)
+	report.Valid = report.ErrorCount == 0
+	return report
+}
+
+func (v *Validator) lineError(line int64, reason string) {
+	v.report.ErrorCount++
+	if len(v.report.Errors) < MaxReportedErrors {
+		v.report.Errors = append(v.report.Errors, ingest.VcfLineError{Line: line, Reason: reason})
+	}
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
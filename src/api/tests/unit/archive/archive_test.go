@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"testing"
+
+	"gohan/api/models"
+	"gohan/api/services/archive"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalArchiveStoresByContent(t *testing.T) {
+	bridge := t.TempDir()
+	root := t.TempDir()
+	content := []byte("##fileformat=VCFv4.2\n")
+	assert.Nil(t, os.MkdirAll(path.Join(bridge, "tmp"), 0700))
+	assert.Nil(t, os.WriteFile(path.Join(bridge, "tmp", "a.vcf.gz"), content, 0600))
+	assert.Nil(t, os.WriteFile(path.Join(bridge, "tmp", "b.vcf.gz"), content, 0600))
+
+	cfg := &models.Config{}
+	cfg.Api.BridgeDirectory = bridge
+	cfg.Archive.Backend = "local"
+	cfg.Archive.LocalPath = root
+	fa, err := archive.NewFileArchive(cfg, nil)
+	assert.Nil(t, err)
+
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+
+	first, err := fa.Store(context.Background(), archive.StoreRequest{FileName: "tmp/a.vcf.gz"})
+	assert.Nil(t, err)
+	assert.Equal(t, "local://sha256/"+digest, first)
+
+	// identical content is kept once
+	second, err := fa.Store(context.Background(), archive.StoreRequest{FileName: "tmp/b.vcf.gz"})
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+
+	stored, err := os.ReadFile(path.Join(root, "sha256", digest[:2], digest))
+	assert.Nil(t, err)
+	assert.Equal(t, content, stored)
+
+	entries, err := os.ReadDir(path.Join(root, "sha256"))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1) // no leftover temporary file
+}
+
+func TestUnknownArchiveBackend(t *testing.T) {
+	cfg := &models.Config{}
+	cfg.Archive.Backend = "s3"
+	_, err := archive.NewFileArchive(cfg, nil)
+	assert.NotNil(t, err)
+
+	cfg.Archive.Backend = "local"
+	_, err = archive.NewFileArchive(cfg, nil)
+	assert.NotNil(t, err) // without a path
+}
@@ -0,0 +1,125 @@
+package drs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gohan/api/models"
+	drsModels "gohan/api/models/drs"
+	"gohan/api/services/drs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(url string, maxRetries uint64) *drs.Client {
+	cfg := &models.Config{}
+	cfg.Drs.Url = url
+	cfg.Drs.Timeout = time.Second
+	cfg.Drs.MaxRetries = maxRetries
+	cfg.Drs.InitialBackoff = time.Millisecond
+	return drs.NewClient(cfg)
+}
+
+func TestIngestRetriesTransientFailures(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			// rejected before being processed
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		assert.Equal(t, "/ingest", r.URL.Path)
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "/data/tmp/file.vcf.gz", r.Form.Get("path"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": "1234", "size": 42, "checksums": [{"checksum": "abcd", "type": "sha-256"}]}`)
+	}))
+	defer server.Close()
+
+	object, err := newClient(server.URL, 5).Ingest(context.Background(), drsModels.IngestRequest{Path: "/data/tmp/file.vcf.gz"}, "Bearer token")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), attempts)
+	assert.Equal(t, "1234", object.Id)
+	assert.Equal(t, "sha-256", object.Checksums[0].Type)
+}
+
+func TestIngestIsNotRetriedOnceProcessingMayHaveBegun(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// i.e. database locked, after the file was copied
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := newClient(server.URL, 5).Ingest(context.Background(), drsModels.IngestRequest{Path: "/data/tmp/file.vcf.gz"}, "")
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), attempts)
+}
+
+func TestGetObjectDoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		assert.Equal(t, "/ga4gh/drs/v1/objects/missing", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"msg": "No object found", "status_code": 404}`)
+	}))
+	defer server.Close()
+
+	_, err := newClient(server.URL, 5).GetObject(context.Background(), "missing", "")
+	assert.True(t, errors.Is(err, drs.ErrNotFound))
+	assert.Contains(t, err.Error(), "No object found")
+	assert.Equal(t, int32(1), attempts)
+}
+
+func TestObjectId(t *testing.T) {
+	client := newClient("http://drs.local/", 0)
+	for uri, expected := range map[string]string{
+		"drs://drs.local/1234": "1234",
+		"1234":                 "1234", // recorded before fileIds were URIs
+		"drs://elsewhere/1234": "",     // held by another DRS instance
+		"local://sha256/abcd":  "",
+		"drs://drs.local/":     "",
+		"":                     "",
+	} {
+		id, ok := client.ObjectId(uri)
+		assert.Equal(t, expected, id, uri)
+		assert.Equal(t, expected != "", ok, uri)
+	}
+
+	assert.Equal(t, "drs://drs.local:5000/1234", newClient("http://drs.local:5000/", 0).Uri("1234"))
+}
+
+func TestOpenStreamsFromAccessUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/objects/1234/download", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization")) // served by DRS itself
+		fmt.Fprint(w, "content")
+	}))
+	defer server.Close()
+
+	object := &drsModels.Object{
+		Id: "1234",
+		AccessMethods: []drsModels.AccessMethod{
+			{Type: "file", AccessUrl: &drsModels.AccessUrl{Url: "file:///drs/obj/1234"}},
+			{Type: "http", AccessUrl: &drsModels.AccessUrl{Url: server.URL + "/objects/1234/download"}},
+		},
+	}
+	source, err := newClient(server.URL, 0).Open(context.Background(), object, "Bearer token")
+	assert.Nil(t, err)
+	defer source.Close()
+
+	content, err := io.ReadAll(source)
+	assert.Nil(t, err)
+	assert.Equal(t, "content", string(content))
+}
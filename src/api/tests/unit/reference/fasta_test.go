@@ -0,0 +1,59 @@
+package reference
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"gohan/api/services/reference"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// two contigs, wrapped at 4 bases per line
+const (
+	testFasta = ">chr1 description\nACGT\nacgt\nTT\n>chrM\nGGCC\nA\n"
+	testFai   = "chr1\t10\t18\t4\t5\nchrM\t5\t37\t4\t5\n"
+)
+
+func TestFasta(t *testing.T) {
+	directory := t.TempDir()
+	assert.Nil(t, os.WriteFile(path.Join(directory, "GRCh38.fa"), []byte(testFasta), 0644))
+	assert.Nil(t, os.WriteFile(path.Join(directory, "GRCh38.fa.fai"), []byte(testFai), 0644))
+
+	genomes := reference.NewGenomes(directory)
+
+	_, err := genomes.Get("GRCh37")
+	assert.ErrorIs(t, err, reference.ErrNoReferenceGenome)
+
+	fasta, err := genomes.Get("GRCh38")
+	assert.Nil(t, err)
+
+	// contigs are looked up without their 'chr' prefix
+	contig, known := fasta.Contig("1")
+	assert.True(t, known)
+	assert.Equal(t, "chr1", contig)
+	mitochondrial, known := fasta.Contig("MT")
+	assert.True(t, known)
+	assert.Equal(t, "chrM", mitochondrial)
+	_, known = fasta.Contig("2")
+	assert.False(t, known)
+
+	for _, tc := range []struct {
+		contig     string
+		start, end int
+		expected   string
+	}{
+		{"chr1", 1, 1, "A"},
+		{"chr1", 3, 6, "GTAC"}, // across lines, upper cased
+		{"chr1", 8, 10, "TTT"},
+		{"chrM", 4, 5, "CA"},
+	} {
+		bases, fetchErr := fasta.Fetch(tc.contig, tc.start, tc.end)
+		assert.Nil(t, fetchErr)
+		assert.Equal(t, tc.expected, bases)
+	}
+
+	_, err = fasta.Fetch("chr1", 10, 11)
+	assert.NotNil(t, err)
+}
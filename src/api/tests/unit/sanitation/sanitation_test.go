@@ -0,0 +1,54 @@
+package sanitation
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"gohan/api/models/indexes"
+	esRepo "gohan/api/repositories/elasticsearch"
+	"gohan/api/services/sanitation"
+	"gohan/api/services/vcf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateDocumentIds(t *testing.T) {
+	call := indexes.Variant{
+		Chrom:      "1",
+		Pos:        12345,
+		Ref:        []string{"A"},
+		Alt:        []string{"G"},
+		Sample:     indexes.Sample{Id: "sample"},
+		Dataset:    "00000000-0000-0000-0000-000000000000",
+		AssemblyId: "GRCh38",
+	}
+	other := call
+	other.Alt = []string{"T"}
+
+	// most recent first
+	group := []esRepo.VariantDocument{
+		{Id: "newest", Variant: call},
+		{Id: "other", Variant: other},
+		{Id: "oldest", Variant: call},
+	}
+	assert.Equal(t, []string{"oldest"}, sanitation.DuplicateDocumentIds(group))
+
+	// the document stored under the id derived from the call is kept
+	group = append(group, esRepo.VariantDocument{Id: vcf.DocumentId(&call), Variant: call})
+	assert.Equal(t, []string{"oldest", "newest"}, sanitation.DuplicateDocumentIds(group))
+
+	assert.Empty(t, sanitation.DuplicateDocumentIds(group[:2]))
+}
+
+func TestFileDatasetCatalogue(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "datasets.txt")
+	assert.NoError(t, os.WriteFile(filePath, []byte("# known datasets\nfirst\n\n  second  \n"), 0644))
+
+	ids, err := (&sanitation.FileDatasetCatalogue{Path: filePath}).DatasetIds()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, ids)
+
+	_, err = (&sanitation.FileDatasetCatalogue{Path: path.Join(t.TempDir(), "missing.txt")}).DatasetIds()
+	assert.Error(t, err)
+}
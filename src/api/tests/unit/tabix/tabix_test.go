@@ -0,0 +1,212 @@
+package tabix
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"gohan/api/services/bgzf"
+	"gohan/api/services/tabix"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const header = "##fileformat=VCFv4.2\n#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n"
+
+func TestBgzf(t *testing.T) {
+	var (
+		data    bytes.Buffer
+		buf     bytes.Buffer
+		offsets = map[int]bgzf.VirtualOffset{}
+	)
+	bw := bgzf.NewWriter(&buf)
+	for i := 0; i < 20000; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		offsets[i] = bw.VirtualOffset()
+		data.WriteString(line)
+		_, err := bw.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, bw.Close())
+
+	// readable as any gzip file
+	gr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	decompressed, err := io.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, data.Bytes(), decompressed)
+
+	isBgzf, err := bgzf.IsBgzf(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.True(t, isBgzf)
+
+	var plain bytes.Buffer
+	gw := gzip.NewWriter(&plain)
+	gw.Write(data.Bytes())
+	gw.Close()
+	isBgzf, err = bgzf.IsBgzf(bytes.NewReader(plain.Bytes()))
+	assert.NoError(t, err)
+	assert.False(t, isBgzf)
+
+	// any line can be found back by its virtual offset
+	br := bgzf.NewReader(bytes.NewReader(buf.Bytes()))
+	for _, i := range []int{0, 1, 9999, 19999} {
+		assert.NoError(t, br.Seek(offsets[i]))
+		line, err := br.ReadLine()
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("line %d", i), string(line))
+	}
+
+	// corruption is pinpointed
+	corrupted := append([]byte{}, buf.Bytes()...)
+	corrupted[30] ^= 0xff
+	_, err = io.ReadAll(bgzf.NewReader(bytes.NewReader(corrupted)))
+	assert.ErrorContains(t, err, "block at offset 0")
+
+	_, err = io.ReadAll(bgzf.NewReader(bytes.NewReader(plain.Bytes())))
+	assert.ErrorContains(t, err, "not a BGZF block")
+}
+
+func TestIndexVcf(t *testing.T) {
+	var vcf strings.Builder
+	vcf.WriteString(header)
+	for _, chrom := range []string{"1", "2"} {
+		for pos := 1; pos <= 200000; pos += 10 {
+			fmt.Fprintf(&vcf, "%s\t%d\t.\tA\tG\t50\tPASS\tDP=10\n", chrom, pos)
+		}
+	}
+	vcf.WriteString("2\t300000\t.\tN\t<DEL>\t50\tPASS\tSVTYPE=DEL;END=400000\n")
+
+	// merely gzipped : re-compressed as BGZF first
+	filePath := path.Join(t.TempDir(), "test.vcf.gz")
+	writeGzip(t, filePath, vcf.String())
+
+	indexPath, err := tabix.IndexVcf(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, filePath+".tbi", indexPath)
+
+	f, err := os.Open(filePath)
+	assert.NoError(t, err)
+	defer f.Close()
+	isBgzf, err := bgzf.IsBgzf(f)
+	assert.NoError(t, err)
+	assert.True(t, isBgzf)
+
+	index := readIndex(t, indexPath)
+	assert.Equal(t, "TBI\x01", string(index.next(4)))
+	assert.Equal(t, int32(2), index.int32()) // contigs
+	assert.Equal(t, []int32{2, 1, 2, 0, '#', 0}, []int32{index.int32(), index.int32(), index.int32(), index.int32(), index.int32(), index.int32()})
+	assert.Equal(t, "1\x002\x00", string(index.next(int(index.int32()))))
+
+	// the linear index of contig 1 leads to the first record of each 16kb window
+	index.skipBins()
+	windows := index.int32()
+	assert.Equal(t, int32(200000>>14+1), windows)
+	linear := make([]bgzf.VirtualOffset, windows)
+	for i := range linear {
+		linear[i] = bgzf.VirtualOffset(index.uint64())
+	}
+
+	br := bgzf.NewReader(f)
+	for _, window := range []int{0, 3, 12} {
+		assert.NoError(t, br.Seek(linear[window]))
+		line, err := br.ReadLine()
+		assert.NoError(t, err)
+
+		// the first position of the window, rounded up to the next record
+		first := window<<14 + 1
+		first += (10 - (first-1)%10) % 10
+		assert.Equal(t, fmt.Sprintf("1\t%d\t", first), string(line[:len(fmt.Sprintf("1\t%d\t", first))]))
+	}
+
+	// contig 2 spans up to the END of the deletion
+	index.skipBins()
+	assert.Equal(t, int32(400000>>14)+1, index.int32())
+}
+
+func TestIndexVcfCsi(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "large.vcf.gz")
+	writeGzip(t, filePath, header+"1\t100\t.\tA\tG\t50\tPASS\t.\n1\t600000000\t.\tA\tG\t50\tPASS\t.\n")
+
+	indexPath, err := tabix.IndexVcf(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, filePath+".csi", indexPath)
+
+	index := readIndex(t, indexPath)
+	assert.Equal(t, "CSI\x01", string(index.next(4)))
+	assert.Equal(t, int32(14), index.int32()) // min_shift
+	assert.Equal(t, int32(6), index.int32())  // depth
+}
+
+func TestIndexVcfUnsorted(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "unsorted.vcf.gz")
+	writeGzip(t, filePath, header+"1\t200\t.\tA\tG\t50\tPASS\t.\n1\t100\t.\tA\tG\t50\tPASS\t.\n")
+	_, err := tabix.IndexVcf(filePath)
+	assert.ErrorContains(t, err, "line 4: 1:100 comes after 1:200")
+
+	writeGzip(t, filePath, header+"1\t100\t.\tA\tG\t50\tPASS\t.\n2\t100\t.\tA\tG\t50\tPASS\t.\n1\t200\t.\tA\tG\t50\tPASS\t.\n")
+	_, err = tabix.IndexVcf(filePath)
+	assert.ErrorContains(t, err, "line 5: the records of contig 1 are not contiguous")
+
+	writeGzip(t, filePath, header+"1\tabc\t.\tA\tG\t50\tPASS\t.\n")
+	_, err = tabix.IndexVcf(filePath)
+	assert.ErrorContains(t, err, "line 3: invalid POS 'abc'")
+
+	assert.NoError(t, os.WriteFile(filePath, []byte(header), 0644))
+	_, err = tabix.IndexVcf(filePath)
+	assert.ErrorContains(t, err, "not gzip-compressed")
+}
+
+func writeGzip(t *testing.T, filePath string, content string) {
+	f, err := os.Create(filePath)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	_, err = gw.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, gw.Close())
+}
+
+type indexReader struct {
+	data []byte
+}
+
+func readIndex(t *testing.T, indexPath string) *indexReader {
+	f, err := os.Open(indexPath)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	data, err := io.ReadAll(bgzf.NewReader(f))
+	assert.NoError(t, err)
+	return &indexReader{data: data}
+}
+
+func (ir *indexReader) next(n int) []byte {
+	b := ir.data[:n]
+	ir.data = ir.data[n:]
+	return b
+}
+
+func (ir *indexReader) int32() int32 {
+	return int32(binary.LittleEndian.Uint32(ir.next(4)))
+}
+
+func (ir *indexReader) uint64() uint64 {
+	return binary.LittleEndian.Uint64(ir.next(8))
+}
+
+func (ir *indexReader) skipBins() {
+	bins := ir.int32()
+	for i := int32(0); i < bins; i++ {
+		ir.next(4) // bin
+		chunks := ir.int32()
+		ir.next(int(chunks) * 16)
+	}
+}
@@ -0,0 +1,49 @@
+package vcf
+
+import (
+	"testing"
+
+	"gohan/api/models/indexes"
+	"gohan/api/services/vcf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormatField(t *testing.T) {
+	header := vcf.NewHeader()
+	header.AddMetaLine(`##FORMAT=<ID=DP,Number=1,Type=Integer,Description="Read Depth">`)
+	header.AddMetaLine(`##FORMAT=<ID=AD,Number=R,Type=Integer,Description="Allelic depths">`)
+
+	variation := &indexes.Variation{}
+	for id, raw := range map[string]string{
+		"DP":     "23",
+		"GQ":     "99.4",
+		"MIN_DP": ".",
+		"AD":     "10,.,13",
+		"AF":     "0.565",
+		"SB":     "5,5,6,7",
+		"PS":     "12345",
+	} {
+		vcf.ParseFormatField(variation, id, raw, header.Format)
+	}
+
+	assert.Equal(t, int64(23), *variation.ReadDepth)
+	assert.Equal(t, int64(99), *variation.GenotypeQuality)
+	assert.Nil(t, variation.MinReadDepth)
+	assert.Equal(t, []int64{10, -1, 13}, variation.AllelicDepths)
+	assert.Equal(t, []float64{0.565}, variation.AlleleFractions)
+	assert.Equal(t, []int64{5, 5, 6, 7}, variation.StrandBias)
+
+	// every field is kept generically as well, typed when declared in the header
+	assert.Len(t, variation.Formats, 7)
+	for _, f := range variation.Formats {
+		switch f.Id {
+		case "AD":
+			assert.Equal(t, vcf.Integer, f.Type)
+			assert.Equal(t, []int64{10, 13}, f.Integers)
+		case "PS":
+			assert.Equal(t, "12345", f.Value)
+			assert.Empty(t, f.Type)
+		}
+	}
+}
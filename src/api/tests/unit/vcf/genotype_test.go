@@ -0,0 +1,69 @@
+package vcf
+
+import (
+	"testing"
+
+	"gohan/api/models/constants"
+	z "gohan/api/models/constants/zygosity"
+	"gohan/api/services/vcf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGenotype(t *testing.T) {
+	for _, tc := range []struct {
+		gt               string
+		alleleIndexes    []int
+		phased           bool
+		phasedSeparators []bool
+		zygosity         constants.Zygosity
+	}{
+		// haploid
+		{"0", []int{0}, false, nil, z.Reference},
+		{"2", []int{2}, false, nil, z.Alternate},
+		{".", []int{0}, false, nil, z.Reference},
+		// diploid
+		{"0/0", []int{0, 0}, false, []bool{false}, z.HomozygousReference},
+		{"0|1", []int{0, 1}, true, []bool{true}, z.Heterozygous},
+		{"1/1", []int{1, 1}, false, []bool{false}, z.HomozygousAlternate},
+		{"1|2", []int{1, 2}, true, []bool{true}, z.HeterozygousAlternate},
+		{"0/.", []int{0, -1}, false, []bool{false}, z.Unknown},
+		{"./.", []int{0, 0}, false, []bool{false}, z.HomozygousReference},
+		// polyploid, with mixed phasing
+		{"0/1/2", []int{0, 1, 2}, false, []bool{false, false}, z.Heterozygous},
+		{"1|1/2", []int{1, 1, 2}, false, []bool{true, false}, z.HeterozygousAlternate},
+		{"2|2|2|2", []int{2, 2, 2, 2}, true, []bool{true, true, true}, z.HomozygousAlternate},
+		{"0/x/1", []int{0, -1, 1}, false, []bool{false, false}, z.Unknown},
+	} {
+		genotype := vcf.ParseGenotype(tc.gt)
+
+		assert.Equal(t, tc.alleleIndexes, genotype.AlleleIndexes, tc.gt)
+		assert.Equal(t, constants.Ploidy(len(tc.alleleIndexes)), genotype.Ploidy, tc.gt)
+		assert.Equal(t, tc.phased, genotype.Phased, tc.gt)
+		assert.Equal(t, tc.phasedSeparators, genotype.PhasedSeparators, tc.gt)
+		assert.Equal(t, tc.zygosity, genotype.Zygosity, tc.gt)
+	}
+}
+
+func TestGenotypeAlleles(t *testing.T) {
+	ref := []string{"G"}
+	alt := []string{"CT", "CTT"}
+
+	assert.Equal(t, []string{"G", "CT", "CTT"}, vcf.GenotypeAlleles([]int{0, 1, 2}, ref, alt))
+	assert.Equal(t, []string{"CTT"}, vcf.GenotypeAlleles([]int{2}, ref, alt))
+	// unknown and out-of-range alleles resolve to the reference
+	assert.Equal(t, []string{"G", "G"}, vcf.GenotypeAlleles([]int{-1, 3}, ref, alt))
+}
+
+func TestIsReferenceCall(t *testing.T) {
+	for gt, expected := range map[string]bool{
+		"0":       true,
+		"0|0":     true,
+		"0/0/0/0": true,
+		"0/1":     false,
+		"./.":     false,
+		"":        false,
+	} {
+		assert.Equal(t, expected, vcf.IsReferenceCall(gt), gt)
+	}
+}
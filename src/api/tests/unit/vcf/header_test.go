@@ -0,0 +1,132 @@
+package vcf
+
+import (
+	"strings"
+	"testing"
+
+	"gohan/api/models/indexes"
+	"gohan/api/services/vcf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStructuredMetaLine(t *testing.T) {
+	key, fields, ok := vcf.ParseStructuredMetaLine(`##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth, \"across\" samples">`)
+
+	assert.True(t, ok)
+	assert.Equal(t, "INFO", key)
+	assert.Equal(t, "DP", fields["ID"])
+	assert.Equal(t, "1", fields["Number"])
+	assert.Equal(t, "Integer", fields["Type"])
+	assert.Equal(t, `Total Depth, "across" samples`, fields["Description"])
+
+	_, _, ok = vcf.ParseStructuredMetaLine("##fileformat=VCFv4.2")
+	assert.False(t, ok)
+}
+
+func TestParseInfo(t *testing.T) {
+	header := vcf.NewHeader()
+	for _, line := range []string{
+		`##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth">`,
+		`##INFO=<ID=AF,Number=A,Type=Float,Description="Allele Frequency">`,
+		`##INFO=<ID=DB,Number=0,Type=Flag,Description="dbSNP membership">`,
+		`##INFO=<ID=AA,Number=1,Type=String,Description="Ancestral Allele">`,
+	} {
+		header.AddMetaLine(line)
+	}
+
+	infos := vcf.ParseInfo("DP=14;AF=0.5,.,0.017;DB;AA=T;XX=abc;YY", header.Info)
+	assert.Len(t, infos, 6)
+
+	assert.Equal(t, "DP", infos[0].Id)
+	assert.Equal(t, "14", infos[0].Value)
+	assert.Equal(t, vcf.Integer, infos[0].Type)
+	assert.Equal(t, []int64{14}, infos[0].Integers)
+
+	assert.Equal(t, "AF", infos[1].Id)
+	assert.Equal(t, "A", infos[1].Number)
+	assert.Equal(t, []float64{0.5, 0.017}, infos[1].Floats)
+
+	assert.Equal(t, "DB", infos[2].Id)
+	assert.True(t, infos[2].Flag)
+
+	assert.Equal(t, []string{"T"}, infos[3].Strings)
+
+	// undeclared fields are kept as strings only
+	assert.Equal(t, "XX", infos[4].Id)
+	assert.Equal(t, "abc", infos[4].Value)
+	assert.Empty(t, infos[4].Type)
+	assert.Equal(t, "", infos[5].Id)
+	assert.Equal(t, "YY", infos[5].Value)
+}
+
+func TestNumericValuesById(t *testing.T) {
+	header := vcf.NewHeader()
+	header.AddMetaLine(`##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth">`)
+	header.AddMetaLine(`##INFO=<ID=AF,Number=A,Type=Float,Description="Allele Frequency">`)
+	header.AddMetaLine(`##INFO=<ID=AA,Number=1,Type=String,Description="Ancestral Allele">`)
+
+	infos := []indexes.Info{}
+	for _, info := range vcf.ParseInfo("DP=14;AF=0.5,.,0.017;AA=T;XX=3", header.Info) {
+		infos = append(infos, *info)
+	}
+
+	// each field's values are kept apart, such that 'infoTyped.DP >= 10' can't match
+	// a document on the values of another field
+	assert.Equal(t, map[string][]float64{
+		"DP": {14},
+		"AF": {0.5, 0.017},
+	}, vcf.NumericValuesById(infos))
+	assert.Nil(t, vcf.NumericValuesById(nil))
+}
+
+func TestParseInfoTypeMismatch(t *testing.T) {
+	header := vcf.NewHeader()
+	header.AddMetaLine(`##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth">`)
+
+	infos := vcf.ParseInfo("DP=abc", header.Info)
+	assert.Len(t, infos, 1)
+	assert.Equal(t, "abc", infos[0].Value)
+	assert.Empty(t, infos[0].Type)
+	assert.Empty(t, infos[0].Integers)
+}
+
+func TestHeaderDocument(t *testing.T) {
+	header := vcf.NewHeader()
+	for _, line := range []string{
+		"##fileformat=VCFv4.2",
+		"##source=GATK HaplotypeCaller v4.2",
+		"##reference=file:///ref/GRCh38.fa",
+		"##contig=<ID=1,length=248956422>",
+		"##contig=<ID=MT>",
+		`##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">`,
+	} {
+		header.AddMetaLine(line)
+	}
+	header.AddColumnsLine("#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\tHG00096\tHG00097")
+
+	document := header.Document()
+	assert.Equal(t, "VCFv4.2", document.FileFormat)
+	assert.Equal(t, "file:///ref/GRCh38.fa", document.Reference)
+	assert.Len(t, document.MetaLines, 6)
+	assert.Len(t, document.Contigs, 2)
+	assert.Equal(t, int64(248956422), document.Contigs[0].Length)
+	assert.Equal(t, "MT", document.Contigs[1].Id)
+	assert.Equal(t, "GT", header.Format["GT"].Id)
+	assert.Len(t, document.Format, 1)
+	assert.Equal(t, []string{"HG00096", "HG00097"}, header.SampleIds)
+}
+
+func TestReadHeader(t *testing.T) {
+	header, err := vcf.ReadHeader(strings.NewReader(
+		"##fileformat=VCFv4.2\n" +
+			"##contig=<ID=1,length=248956422>\n" +
+			"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\tHG00096\tHG00097\n" +
+			"1\t100\t.\tA\tG\t50\tPASS\t.\tGT\t0/1\t1/1\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "VCFv4.2", header.Document().FileFormat)
+	assert.Equal(t, []string{"HG00096", "HG00097"}, header.SampleIds)
+
+	_, err = vcf.ReadHeader(strings.NewReader("##fileformat=VCFv4.2\n1\t100\t.\tA\tG\t50\tPASS\t.\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package vcf
+
+import (
+	"testing"
+
+	"gohan/api/models/indexes"
+	"gohan/api/services/vcf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentId(t *testing.T) {
+	variant := indexes.Variant{
+		Chrom:      "1",
+		Pos:        12345,
+		Ref:        []string{"A"},
+		Alt:        []string{"G"},
+		Sample:     indexes.Sample{Id: "sample"},
+		Dataset:    "00000000-0000-0000-0000-000000000000",
+		AssemblyId: "GRCh38",
+		FileId:     "first",
+	}
+	id := vcf.DocumentId(&variant)
+	assert.Len(t, id, 64)
+
+	// the same call ingested again, from another file
+	again := variant
+	again.FileId = "second"
+	again.Qual = 50
+	assert.Equal(t, id, vcf.DocumentId(&again))
+
+	// any other call
+	for _, change := range []func(v *indexes.Variant){
+		func(v *indexes.Variant) { v.Chrom = "11" },
+		func(v *indexes.Variant) { v.Pos = 2345 },
+		func(v *indexes.Variant) { v.Alt = []string{"T"} },
+		func(v *indexes.Variant) { v.Sample.Id = "other" },
+		func(v *indexes.Variant) { v.AssemblyId = "GRCh37" },
+	} {
+		other := variant
+		change(&other)
+		assert.NotEqual(t, id, vcf.DocumentId(&other))
+	}
+
+	coverage := indexes.Coverage{Chrom: "1", Start: 100, End: 200, SampleId: "sample"}
+	assert.Equal(t, vcf.CoverageDocumentId(&coverage), vcf.CoverageDocumentId(&indexes.Coverage{Chrom: "1", Start: 100, End: 200, SampleId: "sample"}))
+	assert.NotEqual(t, vcf.CoverageDocumentId(&coverage), vcf.CoverageDocumentId(&indexes.Coverage{Chrom: "1", Start: 100, End: 201, SampleId: "sample"}))
+}
@@ -0,0 +1,162 @@
+package vcf
+
+import (
+	"testing"
+
+	z "gohan/api/models/constants/zygosity"
+	"gohan/api/models/indexes"
+	"gohan/api/services/vcf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAlleles(t *testing.T) {
+	for _, tc := range []struct {
+		pos         int
+		ref         string
+		alt         []string
+		expectedPos int
+		expectedRef string
+		expectedAlt []string
+	}{
+		// already minimal
+		{100, "A", []string{"G"}, 100, "A", []string{"G"}},
+		{100, "C", []string{"CA"}, 100, "C", []string{"CA"}},
+		// trailing bases
+		{100, "CTT", []string{"CT"}, 100, "CT", []string{"C"}},
+		// leading bases
+		{100, "GACT", []string{"GAT"}, 101, "AC", []string{"A"}},
+		// multiple alternate alleles are trimmed together
+		{100, "CAA", []string{"CA", "CAAA"}, 100, "CA", []string{"C", "CAA"}},
+		// symbolic alleles are left as is
+		{100, "CA", []string{"<DEL>"}, 100, "CA", []string{"<DEL>"}},
+		{100, "CA", []string{"CAA", "*"}, 100, "CA", []string{"CAA", "*"}},
+	} {
+		pos, ref, alt := vcf.NormalizeAlleles(tc.pos, tc.ref, tc.alt)
+
+		assert.Equal(t, tc.expectedPos, pos, tc.ref)
+		assert.Equal(t, tc.expectedRef, ref, tc.ref)
+		assert.Equal(t, tc.expectedAlt, alt, tc.ref)
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	variant := indexes.Variant{
+		Chrom: "1",
+		Pos:   100,
+		End:   100,
+		Ref:   []string{"C"},
+		Alt:   []string{"CA", "CAAA"},
+		Info: []indexes.Info{
+			{Id: "AC", Value: "1,1"},
+			{Id: "DP", Value: "30"},
+		},
+		Sample: indexes.Sample{
+			Id: "sample",
+			Variation: indexes.Variation{
+				Genotype:             vcf.ParseGenotype("1|2"),
+				AllelicDepths:        []int64{2, 10, 18},
+				PhredScaleLikelyhood: []float64{90, 40, 60, 50, 0, 70},
+			},
+		},
+	}
+
+	decomposed := vcf.Decompose(variant)
+	assert.Len(t, decomposed, 2)
+
+	first, second := decomposed[0], decomposed[1]
+	assert.Equal(t, []string{"CA"}, first.Alt)
+	assert.Equal(t, []string{"CAAA"}, second.Alt)
+
+	// 1|2 becomes 1|0 and 0|1
+	assert.Equal(t, []int{1, 0}, first.Sample.Variation.Genotype.AlleleIndexes)
+	assert.Equal(t, []int{0, 1}, second.Sample.Variation.Genotype.AlleleIndexes)
+	assert.Equal(t, z.Heterozygous, first.Sample.Variation.Genotype.Zygosity)
+	assert.True(t, second.Sample.Variation.Genotype.Phased)
+	assert.Equal(t, []string{"C", "CAAA"}, second.Sample.Variation.AllAlleles)
+	assert.Equal(t, indexes.AllelePair{Left: "C", Right: "CAAA"}, second.Sample.Variation.Alleles)
+	assert.Equal(t, []string{"C:1", "CAAA:1"}, second.Sample.Variation.AlleleOccurrences)
+
+	// per-allele and per-genotype values
+	assert.Equal(t, []int64{2, 10}, first.Sample.Variation.AllelicDepths)
+	assert.Equal(t, []int64{2, 18}, second.Sample.Variation.AllelicDepths)
+	assert.Equal(t, []float64{90, 40, 60}, first.Sample.Variation.PhredScaleLikelyhood)
+	assert.Equal(t, []float64{90, 50, 70}, second.Sample.Variation.PhredScaleLikelyhood)
+	assert.Equal(t, "1", first.Info[0].Value)
+	assert.Equal(t, "30", first.Info[1].Value)
+	assert.Equal(t, indexes.Info{Id: "OLD_MULTIALLELIC", Value: "1:100:C/CA/CAAA"}, first.Info[2])
+
+	// the original variant is left untouched
+	assert.Equal(t, []int{1, 2}, variant.Sample.Variation.Genotype.AlleleIndexes)
+
+	// decomposed variants can then be normalized
+	vcf.Normalize(&second)
+	assert.Equal(t, []string{"C", "CAAA"}, second.Sample.Variation.AllAlleles)
+	assert.Equal(t, 100, second.End)
+}
+
+func TestNormalize(t *testing.T) {
+	variant := indexes.Variant{
+		Pos: 100,
+		End: 102,
+		Ref: []string{"GAC"},
+		Alt: []string{"GC"},
+		Sample: indexes.Sample{
+			Variation: indexes.Variation{
+				Genotype: vcf.ParseGenotype("0/1"),
+			},
+		},
+	}
+
+	vcf.Normalize(&variant)
+
+	assert.Equal(t, 100, variant.Pos)
+	assert.Equal(t, 101, variant.End)
+	assert.Equal(t, []string{"GA"}, variant.Ref)
+	assert.Equal(t, []string{"G"}, variant.Alt)
+	assert.Equal(t, []string{"GA", "G"}, variant.Sample.Variation.AllAlleles)
+}
+
+func TestLeftAlign(t *testing.T) {
+	//                1       8
+	const sequence = "TTGCACAT"
+	baseAt := func(pos int) (byte, bool) {
+		if pos < 1 || pos > len(sequence) {
+			return 0, false
+		}
+		return sequence[pos-1], true
+	}
+
+	for _, tc := range []struct {
+		pos         int
+		ref         string
+		alt         []string
+		expectedPos int
+		expectedRef string
+		expectedAlt []string
+	}{
+		// deletion of the second 'CA' of 'GCACA'
+		{5, "ACA", []string{"A"}, 3, "GCA", []string{"G"}},
+		// insertion of a 'CA'
+		{7, "A", []string{"ACA"}, 3, "G", []string{"GCA"}},
+		// SNVs are left as is
+		{5, "A", []string{"T"}, 5, "A", []string{"T"}},
+		// already left-aligned
+		{3, "GCA", []string{"G"}, 3, "GCA", []string{"G"}},
+	} {
+		pos, ref, alt := vcf.LeftAlign(tc.pos, tc.ref, tc.alt, baseAt)
+
+		assert.Equal(t, tc.expectedPos, pos, tc.ref)
+		assert.Equal(t, tc.expectedRef, ref, tc.ref)
+		assert.Equal(t, tc.expectedAlt, alt, tc.ref)
+	}
+}
+
+func TestRefMatches(t *testing.T) {
+	assert.True(t, vcf.RefMatches("ACGT", "ACGT"))
+	assert.True(t, vcf.RefMatches("acgt", "ACGT"))
+	assert.True(t, vcf.RefMatches("ANGT", "ACGT"))
+	assert.True(t, vcf.RefMatches("ACGT", "ACNT"))
+	assert.False(t, vcf.RefMatches("ACGA", "ACGT"))
+	assert.False(t, vcf.RefMatches("ACG", "ACGT"))
+}
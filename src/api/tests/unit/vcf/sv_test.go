@@ -0,0 +1,46 @@
+package vcf
+
+import (
+	"testing"
+
+	"gohan/api/models/indexes"
+	"gohan/api/services/vcf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantExtent(t *testing.T) {
+	header := vcf.NewHeader()
+	header.AddMetaLine(`##INFO=<ID=END,Number=1,Type=Integer,Description="End position">`)
+	header.AddMetaLine(`##INFO=<ID=SVLEN,Number=A,Type=Integer,Description="Length of the SV">`)
+	header.AddMetaLine(`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of the SV">`)
+
+	for _, tc := range []struct {
+		name     string
+		ref, alt []string
+		info     string
+		expected vcf.Extent
+	}{
+		{"snv", []string{"A"}, []string{"G"}, "DP=10", vcf.Extent{End: 1000}},
+		{"multi-base ref", []string{"ACGT"}, []string{"A"}, "DP=10", vcf.Extent{End: 1003}},
+		{"end", []string{"N"}, []string{"<DEL>"}, "SVTYPE=DEL;END=51000;SVLEN=-50000", vcf.Extent{End: 51000, SvType: "DEL", SvLen: 50000}},
+		{"svlen only", []string{"N"}, []string{"<DUP:TANDEM>"}, "SVLEN=300", vcf.Extent{End: 1300, SvType: "DUP", SvLen: 300}},
+		{"insertion", []string{"N"}, []string{"<INS>"}, "SVTYPE=INS;SVLEN=300", vcf.Extent{End: 1000, SvType: "INS", SvLen: 300}},
+		{"breakend", []string{"G"}, []string{"G]17:198982]"}, "", vcf.Extent{End: 1000, SvType: "BND"}},
+		{"undeclared end", []string{"N"}, []string{"<INV>"}, "END=2000", vcf.Extent{End: 2000, SvType: "INV"}},
+	} {
+		infos := vcf.ParseInfo(tc.info, header.Info)
+		if tc.name == "undeclared end" {
+			infos = vcf.ParseInfo(tc.info, map[string]indexes.VcfFieldDefinition{})
+		}
+
+		assert.Equal(t, tc.expected, vcf.VariantExtent(1000, tc.ref, tc.alt, infos), tc.name)
+	}
+}
+
+func TestIsReferenceBlock(t *testing.T) {
+	assert.True(t, vcf.IsReferenceBlock([]string{"<NON_REF>"}))
+	assert.True(t, vcf.IsReferenceBlock([]string{"<*>"}))
+	assert.False(t, vcf.IsReferenceBlock([]string{"G", "<NON_REF>"}))
+	assert.False(t, vcf.IsReferenceBlock([]string{"<DEL>"}))
+}
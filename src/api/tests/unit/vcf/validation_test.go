@@ -0,0 +1,72 @@
+package vcf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gohan/api/services/vcf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRow(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		expected []string
+	}{
+		{"1\t100\t.\tA\tG\t50\tPASS\tDP=10", nil},
+		{"chrX\t100\trs1\tACGT\tA,<DEL>,*\t.\t.\t.", nil},
+		{"2\t321682\t.\tT\t]13:123456]T\t6\tPASS\tSVTYPE=BND", nil},
+		{"1\t100\t.\tA", []string{"expected at least 8 columns, got 4"}},
+		{".\tabc\t.\tA\tG\t50\tPASS\t.", []string{"missing CHROM", "invalid POS 'abc'"}},
+		{"1\t100\t.\tA-\tG?\thigh\tPASS\t.", []string{"invalid REF 'A-'", "invalid ALT 'G?'", "invalid QUAL 'high'"}},
+	} {
+		assert.Equal(t, tc.expected, vcf.ValidateRow(strings.Split(tc.line, "\t")), tc.line)
+	}
+}
+
+func TestValidateGenotype(t *testing.T) {
+	for _, gt := range []string{"0/1", "1|2", "./.", ".", "0/1/2/2"} {
+		assert.NoError(t, vcf.ValidateGenotype(gt, 2), gt)
+	}
+	for _, gt := range []string{"", "0/3", "0/x", "0/", "/1", "0//1"} {
+		assert.Error(t, vcf.ValidateGenotype(gt, 2), gt)
+	}
+}
+
+func TestValidator(t *testing.T) {
+	header := vcf.NewHeader()
+	header.AddMetaLine("##contig=<ID=chr1,length=248956422>")
+	header.AddMetaLine("##contig=<ID=chr2,length=242193529>")
+	header.AddColumnsLine("#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\tS1\tS2")
+
+	validator := vcf.NewValidator("test.vcf.gz")
+	validator.SetHeader(header)
+
+	validator.Record("chr2")
+	validator.Record("chr1")
+	validator.Record("chr1")
+	validator.Record("chrUn_gl000220")
+	validator.MalformedGenotype(12, "S2", fmt.Errorf("invalid allele 'x' in genotype '0/x'"))
+	validator.LineError(10, "invalid POS 'abc'")
+
+	report := validator.Report()
+	assert.False(t, report.Valid)
+	assert.Equal(t, 2, report.SampleCount)
+	assert.Equal(t, int64(4), report.RecordCount)
+	assert.Equal(t, []string{"chr1", "chr2", "chrUn_gl000220"}, report.ContigsSeen)
+	assert.Equal(t, []string{"chrUn_gl000220"}, report.UnknownContigs)
+	assert.Equal(t, int64(1), report.MalformedGenotypes)
+	assert.Equal(t, int64(2), report.ErrorCount)
+	assert.Equal(t, int64(10), report.Errors[0].Line)
+	assert.Equal(t, "sample S2: invalid allele 'x' in genotype '0/x'", report.Errors[1].Reason)
+
+	// only the first errors are listed
+	for line := int64(0); line < vcf.MaxReportedErrors; line++ {
+		validator.LineError(100+line, "missing CHROM")
+	}
+	report = validator.Report()
+	assert.Len(t, report.Errors, vcf.MaxReportedErrors)
+	assert.Equal(t, int64(vcf.MaxReportedErrors+2), report.ErrorCount)
+}
@@ -1,9 +1,17 @@
 package utils
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
 )
 
 func GetRequestReturnStuff[T any](url string) T {
@@ -23,3 +31,71 @@ func GetRequestReturnStuff[T any](url string) T {
 
 	return objects
 }
+
+// CountingReader keeps an atomic tally of the bytes read from the underlying reader
+type CountingReader struct {
+	Reader io.Reader
+	Count  *int64
+}
+
+func (r *CountingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	atomic.AddInt64(r.Count, int64(n))
+	return n, err
+}
+
+// ParseChecksum splits a client-supplied checksum of the form "<algorithm>:<hex digest>"
+// (i.e. "sha256:9f86d0..."). A bare digest is assumed to be sha256
+func ParseChecksum(checksum string) (string, string, error) {
+	algorithm, digest := "sha256", checksum
+	if parts := strings.SplitN(checksum, ":", 2); len(parts) == 2 {
+		algorithm, digest = strings.ToLower(parts[0]), parts[1]
+	}
+
+	if len(digest) == 0 {
+		return "", "", fmt.Errorf("missing checksum digest")
+	}
+	if NewChecksumHash(algorithm) == nil {
+		return "", "", fmt.Errorf("unsupported checksum algorithm %s (supported: %s)", algorithm, strings.Join(SupportedChecksumAlgorithms, ", "))
+	}
+
+	return algorithm, strings.ToLower(digest), nil
+}
+
+var SupportedChecksumAlgorithms = []string{"sha256", "md5"}
+
+// NewChecksumHash returns the hash matching a supported checksum algorithm, or nil
+func NewChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "sha256":
+		return sha256.New()
+	case "md5":
+		return md5.New()
+	default:
+		return nil
+	}
+}
+
+// VerifyFileChecksum hashes a file on disk and compares it with a client-supplied checksum
+func VerifyFileChecksum(filePath string, checksum string) error {
+	algorithm, expected, err := ParseChecksum(checksum)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := NewChecksumHash(algorithm)
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	if actual := fmt.Sprintf("%x", h.Sum(nil)); actual != expected {
+		return fmt.Errorf("%s checksum mismatch: expected %s, got %s", algorithm, expected, actual)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"math/rand"
 	"strings"
 	"time"
@@ -77,6 +78,18 @@ func RemoveDuplicates(arr []string) []string {
 	return desired_output
 }
 
+// NumberOccurrences tags each value with its occurrence number, i.e. [A T T] gives
+// [A:1 T:1 T:2], such that multisets can be compared one tag at a time
+func NumberOccurrences(arr []string) []string {
+	occurrences := map[string]int{}
+	numbered := make([]string, len(arr))
+	for i, v := range arr {
+		occurrences[v]++
+		numbered[i] = fmt.Sprintf("%s:%d", v, occurrences[v])
+	}
+	return numbered
+}
+
 func GenerateRandomFixedLengthString(availableCharactersSlice []string, length int) string {
 	// Set the seed for the random number generator
 	rand.Seed(time.Now().UnixNano())